@@ -0,0 +1,193 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package check implements the type-checker rules specific to the
+// pipe operator family: classifying a |> (or |?, |/, |||>) LHS by
+// shape (slice, map, channel, iterseq.Seq/Seq2, or anything else) and
+// resolving its element type well enough to select a lowering. It
+// does not attempt full Go type-checking; ordinary Go type errors are
+// left for the host compiler to report once package codegen has
+// produced real Go source.
+package check
+
+import "github.com/AntiTyping/andy-go/syntax"
+
+// Shape classifies the left-hand operand of a pipe expression.
+type Shape int
+
+const (
+	// ShapeOther is the fallback: the LHS's element type couldn't be
+	// determined locally, or it isn't one of the shapes below.
+	ShapeOther Shape = iota
+	ShapeSlice
+	ShapeMap
+	ShapeChan
+	ShapeSeq
+	ShapeSeq2
+)
+
+// Type describes what a checker could determine about an expression:
+// its Shape, and, where relevant, its element type(s) as they'd need
+// to be written in generated Go source.
+type Type struct {
+	Shape Shape
+	// Elem is the slice/chan/iterseq.Seq element type, or the map
+	// value type. Key is the map key type, or the iterseq.Seq2 key
+	// type. Both may be nil if unknown (the fallback ShapeOther case).
+	Elem, Key syntax.TypeExpr
+}
+
+// FuncSig records a top-level function declaration's shape, needed to
+// propagate a called function's result type as the caller's LHS type
+// (e.g. `makeInts() |> double`).
+type FuncSig struct {
+	Decl *syntax.FuncDecl
+}
+
+// Checker accumulates the declarations of one file and classifies
+// expressions against them.
+type Checker struct {
+	Funcs map[string]*FuncSig
+	Types map[string]*syntax.TypeDecl
+
+	locals map[string]Type // current function's local variable types
+}
+
+func NewChecker(f *syntax.File) *Checker {
+	c := &Checker{
+		Funcs:  make(map[string]*FuncSig),
+		Types:  make(map[string]*syntax.TypeDecl),
+		locals: make(map[string]Type),
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *syntax.FuncDecl:
+			if d.Recv == nil {
+				c.Funcs[d.Name.Name] = &FuncSig{Decl: d}
+			}
+		case *syntax.TypeDecl:
+			c.Types[d.Name.Name] = d
+		}
+	}
+	return c
+}
+
+// ResetLocals begins type-checking a new function body.
+func (c *Checker) ResetLocals() {
+	c.locals = make(map[string]Type)
+}
+
+// SetLocal records the inferred type of a local variable, as derived
+// from the composite literal or call expression that produced it.
+func (c *Checker) SetLocal(name string, t Type) {
+	c.locals[name] = t
+}
+
+// Local returns the previously recorded type of a local variable.
+func (c *Checker) Local(name string) (Type, bool) {
+	t, ok := c.locals[name]
+	return t, ok
+}
+
+// InferType classifies an arbitrary expression appearing as a pipe
+// LHS (or as the source of a var/short-var declaration, so its shape
+// can be recorded for later use).
+func (c *Checker) InferType(x syntax.Expr) Type {
+	switch e := x.(type) {
+	case *syntax.CompositeLit:
+		switch t := e.Type.(type) {
+		case *syntax.SliceType:
+			return Type{Shape: ShapeSlice, Elem: t.Elt}
+		case *syntax.ArrayType:
+			return Type{Shape: ShapeSlice, Elem: t.Elt}
+		case *syntax.MapType:
+			return Type{Shape: ShapeMap, Key: t.Key, Elem: t.Value}
+		}
+	case *syntax.Ident:
+		if t, ok := c.locals[e.Name]; ok {
+			return t
+		}
+	case *syntax.UnaryExpr:
+		if e.Op == syntax.ARROW {
+			return c.InferType(e.X)
+		}
+	case *syntax.CallExpr:
+		if fn, ok := c.funcName(e.Fun); ok {
+			if fn == "make" && len(e.Args) > 0 {
+				if t, ok := e.Args[0].(syntax.TypeExpr); ok {
+					return TypeOf(t)
+				}
+			}
+			if sig, ok := c.Funcs[fn]; ok {
+				return c.resultType(sig.Decl)
+			}
+		}
+	case *syntax.PipeExpr:
+		// A pipe chain's output shape, for classifying a later stage
+		// chained onto this one (e.g. the |? in `xs |> f |? g`).
+		switch e.Op {
+		case syntax.PIPE_MAP:
+			// A two-arg map over a Seq2 collapses to a single-valued
+			// Seq (see codegen's MapSeq2 lowering); every other shape
+			// is preserved, though the element type is no longer
+			// tracked past this stage.
+			in := c.InferType(e.X)
+			if in.Shape == ShapeSeq2 {
+				return Type{Shape: ShapeSeq}
+			}
+			return Type{Shape: in.Shape}
+		case syntax.PIPE_FILTER, syntax.PIPE_PARALLEL:
+			return Type{Shape: ShapeSlice}
+		default: // PIPE_REDUCE: a scalar accumulator, not pipeable further
+			return Type{Shape: ShapeOther}
+		}
+	}
+	return Type{Shape: ShapeOther}
+}
+
+func (c *Checker) funcName(x syntax.Expr) (string, bool) {
+	if id, ok := x.(*syntax.Ident); ok {
+		return id.Name, true
+	}
+	return "", false
+}
+
+// resultType derives the Type of a function's (single) result,
+// recognizing the shapes this fork's pipe lowering cares about: []T,
+// map[K]V, chan T/<-chan T, and the iterseq.Seq/Seq2 aliases.
+func (c *Checker) resultType(d *syntax.FuncDecl) Type {
+	if len(d.Results) != 1 {
+		return Type{Shape: ShapeOther}
+	}
+	return TypeOf(d.Results[0].Type)
+}
+
+// TypeOf classifies a TypeExpr into the shapes this fork's pipe
+// lowering cares about: []T, map[K]V, chan T/<-chan T, and the
+// iterseq.Seq/Seq2 aliases. Shared by resultType (a function's
+// declared result) and codegen's paramLocalType (a parameter's
+// declared type), so both see a pipe over either kind of binding the
+// same way.
+func TypeOf(t syntax.TypeExpr) Type {
+	switch t := t.(type) {
+	case *syntax.SliceType:
+		return Type{Shape: ShapeSlice, Elem: t.Elt}
+	case *syntax.MapType:
+		return Type{Shape: ShapeMap, Key: t.Key, Elem: t.Value}
+	case *syntax.ChanType:
+		return Type{Shape: ShapeChan, Elem: t.Value}
+	case *syntax.GenericType:
+		switch t.Name.Name {
+		case "Seq":
+			if len(t.Args) == 1 {
+				return Type{Shape: ShapeSeq, Elem: t.Args[0]}
+			}
+		case "Seq2":
+			if len(t.Args) == 2 {
+				return Type{Shape: ShapeSeq2, Key: t.Args[0], Elem: t.Args[1]}
+			}
+		}
+	}
+	return Type{Shape: ShapeOther}
+}
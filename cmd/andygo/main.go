@@ -0,0 +1,104 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Andygo drives the andy-go pipeline end to end: it parses a .andy
+// source file (syntax.Parse), lowers its pipe expressions to plain Go
+// (package codegen), formats the result, and hands it to the real go
+// toolchain to build or run.
+//
+// Usage:
+//
+//	andygo run file.andy
+//	andygo build file.andy
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/AntiTyping/andy-go/codegen"
+)
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: andygo <run|build> file.andy")
+		os.Exit(2)
+	}
+	cmd, src := os.Args[1], os.Args[2]
+	if cmd != "run" && cmd != "build" {
+		fmt.Fprintf(os.Stderr, "andygo: unknown command %q\n", cmd)
+		os.Exit(2)
+	}
+
+	dir, err := generate(src)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "andygo: %v\n", err)
+		os.Exit(1)
+	}
+
+	var goCmd *exec.Cmd
+	switch cmd {
+	case "run":
+		goCmd = exec.Command("go", "run", dir)
+	case "build":
+		out := strings.TrimSuffix(filepath.Base(src), filepath.Ext(src))
+		goCmd = exec.Command("go", "build", "-o", out, dir)
+	}
+	goCmd.Stdout = os.Stdout
+	goCmd.Stderr = os.Stderr
+	if err := goCmd.Run(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// generate transpiles src and writes the result under the module's
+// gitignored .andygen scratch directory, named after src so repeated
+// runs overwrite rather than accumulate, and returns that directory.
+func generate(src string) (string, error) {
+	input, err := os.ReadFile(src)
+	if err != nil {
+		return "", err
+	}
+	goSrc, err := codegen.Generate(input)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", src, err)
+	}
+	formatted, err := format.Source([]byte(goSrc))
+	if err != nil {
+		return "", fmt.Errorf("%s: generated invalid Go: %w\n%s", src, err, goSrc)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(src), filepath.Ext(src))
+	dir := filepath.Join(moduleRoot(), ".andygen", name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), formatted, 0o644); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// moduleRoot walks up from the current directory to the nearest
+// go.mod, so andygo can be invoked from anywhere in the module.
+func moduleRoot() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "."
+		}
+		dir = parent
+	}
+}
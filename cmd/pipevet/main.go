@@ -0,0 +1,222 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Pipevet reports expressions that mix a pipe operator (|>, |?, |/,
+// |||>) with another binary operator of adjacent precedence without
+// parentheses, the same class of surprise that `&^` vs `<<` caused
+// historically. See doc/pipe-operators.md for the precedence table
+// this check assumes.
+//
+// Usage:
+//
+//	pipevet file.andy...
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AntiTyping/andy-go/syntax"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: pipevet file.andy...")
+		os.Exit(2)
+	}
+
+	failed := false
+	for _, path := range os.Args[1:] {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "pipevet: %v\n", err)
+			failed = true
+			continue
+		}
+		f, err := syntax.Parse(src)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "pipevet: %s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		if inspectFile(path, src, f) {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// inspectFile walks every pipe expression in f and reports whether it
+// found a mixed-precedence one.
+func inspectFile(path string, src []byte, f *syntax.File) bool {
+	found := false
+	for _, d := range f.Decls {
+		walkDecl(d, func(pe *syntax.PipeExpr) {
+			if report(path, src, pe) {
+				found = true
+			}
+		})
+	}
+	return found
+}
+
+// report prints a warning when pe's X or Y operand is itself a binary
+// (non-pipe) expression, and reports whether it found anything. A
+// parenthesized operand parses as *syntax.ParenExpr, not
+// *syntax.BinaryExpr, so it never matches here — that's the point.
+func report(path string, src []byte, pe *syntax.PipeExpr) bool {
+	found := false
+	for _, operand := range []syntax.Expr{pe.X, pe.Y} {
+		be, ok := operand.(*syntax.BinaryExpr)
+		if !ok {
+			continue
+		}
+		if syntax.IsPipeOp(be.Op) {
+			continue // both pipes: unambiguous, left-associative chaining
+		}
+		fmt.Printf("%s: mixed %q and %q without parentheses; wrap the %q operand\n",
+			position(path, src, be.Pos()), pe.Op, be.Op, be.Op)
+		found = true
+	}
+	return found
+}
+
+// position formats a byte offset as "path:line:col", the same shape
+// go/token.FileSet produces, so warnings stay attributable across a
+// multi-file pipevet invocation and jump-to-line in an editor.
+func position(path string, src []byte, offset int) string {
+	line, col := 1, 1
+	for _, b := range src[:offset] {
+		if b == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return fmt.Sprintf("%s:%d:%d", path, line, col)
+}
+
+// walkDecl and the functions below visit every expression reachable
+// from a top-level declaration, calling visit on each *syntax.PipeExpr
+// found. This is a small, purpose-built traversal rather than a
+// general-purpose ast.Inspect equivalent, since pipevet is the only
+// caller that needs one.
+func walkDecl(d syntax.Decl, visit func(*syntax.PipeExpr)) {
+	switch d := d.(type) {
+	case *syntax.FuncDecl:
+		if d.Body != nil {
+			walkBlock(d.Body, visit)
+		}
+	case *syntax.VarDecl:
+		for _, v := range d.Values {
+			walkExpr(v, visit)
+		}
+	}
+}
+
+func walkBlock(b *syntax.BlockStmt, visit func(*syntax.PipeExpr)) {
+	for _, s := range b.List {
+		walkStmt(s, visit)
+	}
+}
+
+func walkStmt(s syntax.Stmt, visit func(*syntax.PipeExpr)) {
+	switch s := s.(type) {
+	case *syntax.VarDecl:
+		for _, v := range s.Values {
+			walkExpr(v, visit)
+		}
+	case *syntax.ExprStmt:
+		walkExpr(s.X, visit)
+	case *syntax.DeferStmt:
+		walkExpr(s.Call, visit)
+	case *syntax.GoStmt:
+		walkExpr(s.Call, visit)
+	case *syntax.SendStmt:
+		walkExpr(s.Chan, visit)
+		walkExpr(s.Value, visit)
+	case *syntax.AssignStmt:
+		for _, x := range s.Lhs {
+			walkExpr(x, visit)
+		}
+		for _, x := range s.Rhs {
+			walkExpr(x, visit)
+		}
+	case *syntax.ReturnStmt:
+		for _, x := range s.Results {
+			walkExpr(x, visit)
+		}
+	case *syntax.IfStmt:
+		if s.Init != nil {
+			walkStmt(s.Init, visit)
+		}
+		walkExpr(s.Cond, visit)
+		walkBlock(s.Body, visit)
+		if s.Else != nil {
+			walkStmt(s.Else, visit)
+		}
+	case *syntax.ForStmt:
+		if s.Init != nil {
+			walkStmt(s.Init, visit)
+		}
+		if s.Cond != nil {
+			walkExpr(s.Cond, visit)
+		}
+		if s.Post != nil {
+			walkStmt(s.Post, visit)
+		}
+		if s.RangeX != nil {
+			walkExpr(s.RangeX, visit)
+		}
+		walkBlock(s.Body, visit)
+	case *syntax.BlockStmt:
+		walkBlock(s, visit)
+	}
+}
+
+func walkExpr(x syntax.Expr, visit func(*syntax.PipeExpr)) {
+	switch x := x.(type) {
+	case *syntax.PipeExpr:
+		walkExpr(x.X, visit)
+		if x.Workers != nil {
+			walkExpr(x.Workers, visit)
+		}
+		if x.Seed != nil {
+			walkExpr(x.Seed, visit)
+		}
+		walkExpr(x.Y, visit)
+		visit(x)
+	case *syntax.BinaryExpr:
+		walkExpr(x.X, visit)
+		walkExpr(x.Y, visit)
+	case *syntax.UnaryExpr:
+		walkExpr(x.X, visit)
+	case *syntax.ParenExpr:
+		walkExpr(x.X, visit)
+	case *syntax.SelectorExpr:
+		walkExpr(x.X, visit)
+	case *syntax.IndexExpr:
+		walkExpr(x.X, visit)
+		for _, i := range x.Index {
+			walkExpr(i, visit)
+		}
+	case *syntax.CallExpr:
+		walkExpr(x.Fun, visit)
+		for _, a := range x.Args {
+			walkExpr(a, visit)
+		}
+	case *syntax.FuncLit:
+		walkBlock(x.Body, visit)
+	case *syntax.CompositeLit:
+		for _, e := range x.Elts {
+			if e.Key != nil {
+				walkExpr(e.Key, visit)
+			}
+			walkExpr(e.Value, visit)
+		}
+	}
+}
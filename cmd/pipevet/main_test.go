@@ -0,0 +1,65 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/AntiTyping/andy-go/syntax"
+)
+
+// inspect parses src as the body of a minimal function and returns
+// what inspectFile reports, plus whatever it printed, so a regression
+// in the mixed-precedence check fails `go test` rather than only the
+// manual `pipevet` recipe.
+func inspect(t *testing.T, src string) (flagged bool, output string) {
+	t.Helper()
+	full := []byte("package p\nfunc f() {\n" + src + "\n}\n")
+	f, err := syntax.Parse(full)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", src, err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Stdout
+	os.Stdout = w
+	flagged = inspectFile("test.andy", full, f)
+	w.Close()
+	os.Stdout = old
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return flagged, string(out)
+}
+
+// TestInspectFileFlagsMixedPrecedence locks in that a + b |> f -- a
+// pipe mixed with an adjacent-precedence binary operator and no
+// parentheses -- is reported.
+func TestInspectFileFlagsMixedPrecedence(t *testing.T) {
+	flagged, out := inspect(t, "x := a + b |> f")
+	if !flagged {
+		t.Fatalf("inspectFile(%q) = false, want true", "a + b |> f")
+	}
+	if !strings.Contains(out, `mixed "|>" and "+"`) {
+		t.Fatalf("output = %q, want a message naming |> and +", out)
+	}
+}
+
+// TestInspectFileSilentOnChainedPipes locks in that a |> b |> c, which
+// chains unambiguously left to right, is not reported.
+func TestInspectFileSilentOnChainedPipes(t *testing.T) {
+	flagged, out := inspect(t, "x := a |> b |> c")
+	if flagged || out != "" {
+		t.Fatalf("inspectFile(%q) = (%v, %q), want (false, \"\")", "a |> b |> c", flagged, out)
+	}
+}
@@ -0,0 +1,646 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package codegen lowers a parsed andy-go *syntax.File into plain Go
+// source text: every pipe expression becomes either a call into
+// runtime/pipe (map/filter/reduce/parallel over a slice, map, channel,
+// or iterseq.Seq/Seq2), a direct Gop_Pipe method call for a
+// user-defined container, or a direct call to a "sink" function whose
+// single parameter is the whole pipe input rather than its element
+// type (`xs |> collect`). The rest of the file is reprinted close to
+// verbatim; gofmt is expected to clean up spacing (see cmd/andygo,
+// which runs it).
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AntiTyping/andy-go/check"
+	"github.com/AntiTyping/andy-go/syntax"
+)
+
+const (
+	pipePkg     = "github.com/AntiTyping/andy-go/runtime/pipe"
+	iterseqPkg  = "github.com/AntiTyping/andy-go/runtime/iterseq"
+	pipeAlias   = "pipe"
+	iterseqName = "iterseq"
+)
+
+// Generator lowers one file at a time.
+type Generator struct {
+	chk *check.Checker
+
+	usesPipe    bool
+	usesIterseq bool
+}
+
+// Generate transpiles src (an andy-go source file) into Go source.
+func Generate(src []byte) (out string, err error) {
+	f, err := syntax.Parse(src)
+	if err != nil {
+		return "", err
+	}
+	g := &Generator{chk: check.NewChecker(f)}
+
+	var b strings.Builder
+	b.WriteString("package ")
+	b.WriteString(f.Name)
+	b.WriteString("\n\n")
+
+	// Imports are rewritten wholesale: the source's own imports, plus
+	// runtime/pipe if this file's lowering needs it. Computing which
+	// are needed requires lowering the declarations first, so decls
+	// are rendered to a buffer before the final import block is
+	// emitted.
+	var body strings.Builder
+	for _, d := range f.Decls {
+		if err := g.genErr(func() { body.WriteString(g.decl(d)) }); err != nil {
+			return "", err
+		}
+		body.WriteString("\n\n")
+	}
+
+	b.WriteString("import (\n")
+	for _, imp := range f.Imports {
+		if imp.Alias != "" {
+			b.WriteString(imp.Alias + " ")
+		}
+		b.WriteString(imp.Path + "\n")
+	}
+	if g.usesPipe && !importsPath(f.Imports, pipePkg) {
+		fmt.Fprintf(&b, "%s %q\n", pipeAlias, pipePkg)
+	}
+	if g.usesIterseq && !importsPath(f.Imports, iterseqPkg) {
+		fmt.Fprintf(&b, "%s %q\n", iterseqName, iterseqPkg)
+	}
+	b.WriteString(")\n\n")
+	b.WriteString(body.String())
+
+	return b.String(), nil
+}
+
+// genErr adapts the panic-based error convention used while walking
+// expressions (mirroring the parser) to a normal error return.
+func (g *Generator) genErr(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ce, ok := r.(*checkError); ok {
+				err = ce
+				return
+			}
+			panic(r)
+		}
+	}()
+	f()
+	return nil
+}
+
+type checkError struct{ msg string }
+
+func (e *checkError) Error() string { return e.msg }
+
+func (g *Generator) fail(format string, args ...any) {
+	panic(&checkError{msg: fmt.Sprintf(format, args...)})
+}
+
+// ---- Declarations ----
+
+func (g *Generator) decl(d syntax.Decl) string {
+	switch d := d.(type) {
+	case *syntax.FuncDecl:
+		return g.funcDecl(d)
+	case *syntax.TypeDecl:
+		return g.typeDecl(d)
+	case *syntax.VarDecl:
+		return g.varDecl(d, "")
+	}
+	g.fail("codegen: unhandled declaration %T", d)
+	return ""
+}
+
+func (g *Generator) funcDecl(d *syntax.FuncDecl) string {
+	var b strings.Builder
+	b.WriteString("func ")
+	if d.Recv != nil {
+		b.WriteString("(")
+		if d.Recv.Name != nil {
+			b.WriteString(d.Recv.Name.Name + " ")
+		}
+		b.WriteString(g.typ(d.Recv.Type))
+		b.WriteString(") ")
+	}
+	b.WriteString(d.Name.Name)
+	b.WriteString(g.typeParams(d.TypeParams))
+	b.WriteString(g.params(d.Params))
+	b.WriteString(g.results(d.Results))
+	b.WriteString(" ")
+
+	g.chk.ResetLocals()
+	if d.Recv != nil && d.Recv.Name != nil {
+		g.chk.SetLocal(d.Recv.Name.Name, check.Type{Shape: check.ShapeOther})
+	}
+	for _, p := range d.Params {
+		if p.Name != nil {
+			g.chk.SetLocal(p.Name.Name, g.paramLocalType(p.Type))
+		}
+	}
+	b.WriteString(g.block(d.Body))
+	return b.String()
+}
+
+// paramLocalType lets a parameter's declared type seed local shape
+// tracking, so a pipe expression over a parameter (not just a literal
+// or another local) still classifies correctly.
+func (g *Generator) paramLocalType(t syntax.TypeExpr) check.Type {
+	return check.TypeOf(t)
+}
+
+func (g *Generator) typeDecl(d *syntax.TypeDecl) string {
+	return "type " + d.Name.Name + g.typeParams(d.TypeParams) + " " + g.typ(d.Type)
+}
+
+func (g *Generator) varDecl(d *syntax.VarDecl, indent string) string {
+	var b strings.Builder
+	b.WriteString(indent + "var ")
+	names := identNames(d.Names)
+	b.WriteString(strings.Join(names, ", "))
+	if d.Type != nil {
+		b.WriteString(" " + g.typ(d.Type))
+	}
+	if len(d.Values) > 0 {
+		b.WriteString(" = ")
+		b.WriteString(g.exprList(d.Values))
+		if len(d.Names) == 1 {
+			g.chk.SetLocal(d.Names[0].Name, g.chk.InferType(d.Values[0]))
+		}
+	}
+	return b.String()
+}
+
+// importsPath reports whether imports already contains path (an
+// unquoted import path, as the pipePkg/iterseqPkg constants are),
+// so Generate doesn't emit a second import of a package the source
+// file already imports itself.
+func importsPath(imports []*syntax.ImportSpec, path string) bool {
+	quoted := `"` + path + `"`
+	for _, imp := range imports {
+		if imp.Path == quoted {
+			return true
+		}
+	}
+	return false
+}
+
+func identNames(ids []*syntax.Ident) []string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = id.Name
+	}
+	return names
+}
+
+// ---- Types ----
+
+func (g *Generator) typeParams(tps []*syntax.TypeParam) string {
+	if len(tps) == 0 {
+		return ""
+	}
+	parts := make([]string, len(tps))
+	for i, tp := range tps {
+		parts[i] = tp.Name.Name + " " + g.typ(tp.Constraint)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func (g *Generator) params(fields []*syntax.Field) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		if f.Name != nil {
+			parts[i] = f.Name.Name + " " + g.typ(f.Type)
+		} else {
+			parts[i] = g.typ(f.Type)
+		}
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func (g *Generator) results(fields []*syntax.Field) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	if len(fields) == 1 && fields[0].Name == nil {
+		return " " + g.typ(fields[0].Type)
+	}
+	return " " + g.params(fields)
+}
+
+func (g *Generator) typ(t syntax.TypeExpr) string {
+	switch t := t.(type) {
+	case *syntax.Ident:
+		return t.Name
+	case *syntax.QualifiedIdent:
+		return t.Pkg.Name + "." + t.Sel.Name
+	case *syntax.SliceType:
+		return "[]" + g.typ(t.Elt)
+	case *syntax.ArrayType:
+		if t.Len == nil {
+			return "[...]" + g.typ(t.Elt)
+		}
+		return "[" + g.expr(t.Len) + "]" + g.typ(t.Elt)
+	case *syntax.MapType:
+		return "map[" + g.typ(t.Key) + "]" + g.typ(t.Value)
+	case *syntax.ChanType:
+		switch t.Dir {
+		case syntax.RecvOnly:
+			return "<-chan " + g.typ(t.Value)
+		case syntax.SendOnly:
+			return "chan<- " + g.typ(t.Value)
+		}
+		return "chan " + g.typ(t.Value)
+	case *syntax.EllipsisType:
+		return "..." + g.typ(t.Elt)
+	case *syntax.FuncType:
+		return "func" + g.params(t.Params) + g.results(t.Results)
+	case *syntax.StructType:
+		var parts []string
+		for _, f := range t.Fields {
+			parts = append(parts, f.Name.Name+" "+g.typ(f.Type))
+		}
+		return "struct{ " + strings.Join(parts, "; ") + " }"
+	case *syntax.InterfaceType:
+		return "interface{}"
+	case *syntax.GenericType:
+		args := make([]string, len(t.Args))
+		for i, a := range t.Args {
+			args[i] = g.typ(a)
+		}
+		name := t.Name.Name
+		if t.Pkg != nil {
+			name = t.Pkg.Name + "." + name
+		}
+		return name + "[" + strings.Join(args, ", ") + "]"
+	}
+	g.fail("codegen: unhandled type %T", t)
+	return ""
+}
+
+// ---- Statements ----
+
+func (g *Generator) block(b *syntax.BlockStmt) string {
+	var out strings.Builder
+	out.WriteString("{\n")
+	for _, s := range b.List {
+		out.WriteString(g.stmt(s))
+		out.WriteString("\n")
+	}
+	out.WriteString("}\n")
+	return out.String()
+}
+
+func (g *Generator) stmt(s syntax.Stmt) string {
+	switch s := s.(type) {
+	case *syntax.VarDecl:
+		return g.varDecl(s, "")
+	case *syntax.ExprStmt:
+		return g.expr(s.X)
+	case *syntax.DeferStmt:
+		return "defer " + g.expr(s.Call)
+	case *syntax.GoStmt:
+		return "go " + g.expr(s.Call)
+	case *syntax.SendStmt:
+		return g.expr(s.Chan) + " <- " + g.expr(s.Value)
+	case *syntax.IncDecStmt:
+		return g.expr(s.X) + s.Op.String()
+	case *syntax.AssignStmt:
+		return g.assignStmt(s)
+	case *syntax.ReturnStmt:
+		if len(s.Results) == 0 {
+			return "return"
+		}
+		return "return " + g.exprList(s.Results)
+	case *syntax.IfStmt:
+		return g.ifStmt(s)
+	case *syntax.ForStmt:
+		return g.forStmt(s)
+	case *syntax.BlockStmt:
+		return g.block(s)
+	}
+	g.fail("codegen: unhandled statement %T", s)
+	return ""
+}
+
+func (g *Generator) assignStmt(s *syntax.AssignStmt) string {
+	op := "="
+	if s.Define {
+		op = ":="
+	}
+	lhsParts := make([]string, len(s.Lhs))
+	for i, l := range s.Lhs {
+		lhsParts[i] = g.expr(l)
+	}
+	out := strings.Join(lhsParts, ", ") + " " + op + " " + g.exprList(s.Rhs)
+	if s.Define && len(s.Lhs) == 1 && len(s.Rhs) == 1 {
+		if id, ok := s.Lhs[0].(*syntax.Ident); ok {
+			g.chk.SetLocal(id.Name, g.chk.InferType(s.Rhs[0]))
+		}
+	}
+	return out
+}
+
+func (g *Generator) ifStmt(s *syntax.IfStmt) string {
+	out := "if "
+	if s.Init != nil {
+		out += g.stmt(s.Init) + "; "
+	}
+	out += g.expr(s.Cond) + " " + g.block(s.Body)
+	if s.Else != nil {
+		out += "else "
+		switch e := s.Else.(type) {
+		case *syntax.IfStmt:
+			out += g.ifStmt(e)
+		case *syntax.BlockStmt:
+			out += g.block(e)
+		}
+	}
+	return out
+}
+
+func (g *Generator) forStmt(s *syntax.ForStmt) string {
+	if s.RangeX != nil {
+		out := "for "
+		if s.Key != nil {
+			out += g.expr(s.Key)
+			if s.Value != nil {
+				out += ", " + g.expr(s.Value)
+			}
+			if s.RangeDefine {
+				out += " := "
+			} else {
+				out += " = "
+			}
+		}
+		out += "range " + g.expr(s.RangeX) + " "
+		return out + g.block(s.Body)
+	}
+
+	out := "for "
+	if s.Init != nil || s.Post != nil {
+		if s.Init != nil {
+			out += g.stmt(s.Init)
+		}
+		out += "; "
+		if s.Cond != nil {
+			out += g.expr(s.Cond)
+		}
+		out += "; "
+		if s.Post != nil {
+			out += g.stmt(s.Post)
+		}
+		out += " "
+	} else if s.Cond != nil {
+		out += g.expr(s.Cond) + " "
+	}
+	return out + g.block(s.Body)
+}
+
+// ---- Expressions ----
+
+func (g *Generator) exprList(xs []syntax.Expr) string {
+	parts := make([]string, len(xs))
+	for i, x := range xs {
+		parts[i] = g.expr(x)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (g *Generator) expr(x syntax.Expr) string {
+	switch x := x.(type) {
+	case *syntax.Ident:
+		return x.Name
+	case *syntax.BasicLit:
+		return x.Value
+	case *syntax.ParenExpr:
+		return "(" + g.expr(x.X) + ")"
+	case *syntax.SelectorExpr:
+		return g.expr(x.X) + "." + x.Sel.Name
+	case *syntax.IndexExpr:
+		idx := make([]string, len(x.Index))
+		for i, e := range x.Index {
+			idx[i] = g.expr(e)
+		}
+		return g.expr(x.X) + "[" + strings.Join(idx, ", ") + "]"
+	case *syntax.CallExpr:
+		args := make([]string, len(x.Args))
+		for i, a := range x.Args {
+			args[i] = g.expr(a)
+		}
+		if x.Ellipsis {
+			args[len(args)-1] += "..."
+		}
+		// Trim a trailing newline same as callArgs does for the last
+		// argument: an immediately-invoked func literal, e.g.
+		// `go func() { ... }()`, would otherwise leave the "()" on its
+		// own line, where ASI breaks it off the literal's closing "}"
+		// and the pair is no longer a valid call expression.
+		fun := strings.TrimRight(g.expr(x.Fun), "\n")
+		return fun + g.callArgs(args...)
+	case *syntax.UnaryExpr:
+		return x.Op.String() + g.expr(x.X)
+	case *syntax.BinaryExpr:
+		return g.expr(x.X) + " " + x.Op.String() + " " + g.expr(x.Y)
+	case *syntax.FuncLit:
+		return "func" + g.params(x.Type.Params) + g.results(x.Type.Results) + " " + g.block(x.Body)
+	case *syntax.CompositeLit:
+		elts := make([]string, len(x.Elts))
+		for i, e := range x.Elts {
+			if e.Key != nil {
+				elts[i] = g.expr(e.Key) + ": " + g.expr(e.Value)
+			} else {
+				elts[i] = g.expr(e.Value)
+			}
+		}
+		// Type is nil for an elided-type nested literal, e.g. the {1, 5}
+		// in [][2]int{{1, 5}, {9, 3}}; real go/parser elides it the same
+		// way, relying on the enclosing literal's element type.
+		typ := ""
+		if x.Type != nil {
+			typ = g.typ(x.Type)
+		}
+		return typ + "{" + strings.Join(elts, ", ") + "}"
+	case *syntax.PipeExpr:
+		return g.pipeExpr(x)
+	case *syntax.SliceType, *syntax.MapType, *syntax.ChanType, *syntax.StructType:
+		// A bare type used as an expression, e.g. the first argument
+		// to make([]T, n), make(map[K]V), or make(chan T), or the
+		// struct{} in a struct{}{} composite literal.
+		return g.typ(x.(syntax.TypeExpr))
+	}
+	g.fail("codegen: unhandled expression %T", x)
+	return ""
+}
+
+// ---- Pipe lowering ----
+
+func (g *Generator) pipeExpr(e *syntax.PipeExpr) string {
+	xText := g.expr(e.X)
+
+	switch e.Op {
+	case syntax.PIPE_FILTER:
+		g.usesPipe = true
+		return pipeAlias + ".Filter" + g.callArgs(xText, g.expr(e.Y))
+	case syntax.PIPE_REDUCE:
+		g.usesPipe = true
+		if e.Seed != nil {
+			return pipeAlias + ".Reduce" + g.callArgs(xText, g.expr(e.Seed), g.expr(e.Y))
+		}
+		return pipeAlias + ".ReduceZero" + g.callArgs(xText, g.expr(e.Y))
+	case syntax.PIPE_PARALLEL:
+		g.usesPipe = true
+		workers := "0"
+		if e.Workers != nil {
+			workers = g.expr(e.Workers)
+		}
+		return pipeAlias + ".ParallelMap" + g.callArgs(xText, workers, g.expr(e.Y))
+	case syntax.PIPE_MAP:
+		return g.pipeMap(e, xText)
+	}
+	g.fail("codegen: unhandled pipe operator %s", e.Op)
+	return ""
+}
+
+// callArgs joins args as a parenthesized argument list with a
+// trailing comma after the last one, whose own trailing newline (left
+// by block(), when the argument is a func literal) is trimmed first.
+// Automatic semicolon insertion would otherwise fire right after that
+// literal's closing brace, since it'd be the last token on its line;
+// the comma has to immediately follow it on the same line instead.
+func (g *Generator) callArgs(args ...string) string {
+	if len(args) == 0 {
+		return "()"
+	}
+	last := len(args) - 1
+	args[last] = strings.TrimRight(args[last], "\n")
+	return "(" + strings.Join(args, ", ") + ",)"
+}
+
+// pipeMap lowers `xs |> f`, dispatching on the shape of xs: a
+// Gop_Pipe method call for a user type following the gop convention
+// for operator overloading (Gop_PointTo, Gop_PointBi, ...); a direct
+// call when f's single parameter is xs's own type rather than its
+// element type (a "sink", e.g. `ch |> collect`); or the matching
+// element-wise runtime/pipe helper for a slice, map, channel, or
+// iterseq.Seq/Seq2.
+func (g *Generator) pipeMap(e *syntax.PipeExpr, xText string) string {
+	xType := g.chk.InferType(e.X)
+	if xType.Shape == check.ShapeOther {
+		// |>'s grammar permits a lower-precedence expression (e.g.
+		// cond || enabled) as X, but a method call's receiver is a
+		// primary expression, so xText needs its own parens here even
+		// though callArgs-based lowerings below never do.
+		return "(" + xText + ")" + ".Gop_Pipe" + g.callArgs(g.expr(e.Y))
+	}
+
+	params := g.rhsParamTypes(e.Y)
+	if len(params) == 1 && (xType.Shape == check.ShapeChan || xType.Shape == check.ShapeSeq) &&
+		g.typeMatchesShape(params[0], xType.Shape) {
+		return g.expr(e.Y) + g.callArgs(xText)
+	}
+
+	yText := g.expr(e.Y)
+	if xType.Shape == check.ShapeSlice {
+		yText = g.maybeInstantiate(e.Y, xType)
+	}
+
+	g.usesPipe = true
+	switch xType.Shape {
+	case check.ShapeMap:
+		if len(params) == 2 {
+			return pipeAlias + ".MapMap2" + g.callArgs(xText, yText)
+		}
+		return pipeAlias + ".MapMap" + g.callArgs(xText, yText)
+	case check.ShapeChan:
+		return pipeAlias + ".MapChan" + g.callArgs(xText, yText)
+	case check.ShapeSeq:
+		g.usesIterseq = true
+		return pipeAlias + ".MapSeq" + g.callArgs(xText, yText)
+	case check.ShapeSeq2:
+		g.usesIterseq = true
+		return pipeAlias + ".MapSeq2" + g.callArgs(xText, yText)
+	}
+	return pipeAlias + ".Map" + g.callArgs(xText, yText)
+}
+
+// rhsParamTypes returns the parameter types of a |> RHS, when it can
+// be resolved locally: a func literal's own signature, or a top-level
+// function (by name, possibly already explicitly instantiated).
+func (g *Generator) rhsParamTypes(y syntax.Expr) []syntax.TypeExpr {
+	switch e := y.(type) {
+	case *syntax.FuncLit:
+		types := make([]syntax.TypeExpr, len(e.Type.Params))
+		for i, p := range e.Type.Params {
+			types[i] = p.Type
+		}
+		return types
+	case *syntax.Ident:
+		if sig, ok := g.chk.Funcs[e.Name]; ok {
+			return fieldTypes(sig.Decl.Params)
+		}
+	case *syntax.IndexExpr:
+		if id, ok := e.X.(*syntax.Ident); ok {
+			if sig, ok := g.chk.Funcs[id.Name]; ok {
+				return fieldTypes(sig.Decl.Params)
+			}
+		}
+	}
+	return nil
+}
+
+func fieldTypes(fields []*syntax.Field) []syntax.TypeExpr {
+	types := make([]syntax.TypeExpr, len(fields))
+	for i, f := range fields {
+		types[i] = f.Type
+	}
+	return types
+}
+
+// typeMatchesShape reports whether t is itself a value of the given
+// shape (a channel type for ShapeChan, an iterseq.Seq instantiation
+// for ShapeSeq) — as opposed to t being that shape's element type.
+func (g *Generator) typeMatchesShape(t syntax.TypeExpr, shape check.Shape) bool {
+	switch shape {
+	case check.ShapeChan:
+		_, ok := t.(*syntax.ChanType)
+		return ok
+	case check.ShapeSeq:
+		gt, ok := t.(*syntax.GenericType)
+		return ok && gt.Name.Name == "Seq"
+	}
+	return false
+}
+
+// maybeInstantiate prints a |> RHS naming a generic top-level
+// function, explicitly instantiating it when it's used bare (no
+// explicit [T] already written) by unifying its first parameter's
+// type with xType's element type. It is an error for the function to
+// declare more type parameters than that single unification
+// determines (pipe_generic_err.go exercises this).
+func (g *Generator) maybeInstantiate(y syntax.Expr, xType check.Type) string {
+	id, ok := y.(*syntax.Ident)
+	if !ok {
+		return g.expr(y)
+	}
+	sig, ok := g.chk.Funcs[id.Name]
+	if !ok || len(sig.Decl.TypeParams) == 0 {
+		return g.expr(y)
+	}
+	if len(sig.Decl.TypeParams) != 1 {
+		g.fail("cannot infer type arguments for %s: unifying its first parameter with the pipe element type only determines one type parameter, but %s declares %d",
+			id.Name, id.Name, len(sig.Decl.TypeParams))
+	}
+	if xType.Elem == nil {
+		g.fail("cannot infer type argument for %s: the pipe input's element type is unknown", id.Name)
+	}
+	return id.Name + "[" + g.typ(xType.Elem) + "]"
+}
@@ -0,0 +1,38 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPipeGenericInferenceFailure locks in the error Generate reports
+// when a |> RHS is an uninstantiated generic function whose type
+// parameters can't all be determined by unifying its first parameter
+// with the pipe's element type -- the case test/pipe_generic_err.go
+// exercises (an "errorcheck" go/test fixture the stock go toolchain
+// can't run, since cmd/andygo is the only thing that parses |>).
+func TestPipeGenericInferenceFailure(t *testing.T) {
+	src := `package main
+
+func pair[T, U any](x T) (T, U) {
+	var u U
+	return x, u
+}
+
+func main() {
+	numbers := []int{1, 2, 3}
+	_ = numbers |> pair
+}
+`
+	_, err := Generate([]byte(src))
+	if err == nil {
+		t.Fatal("Generate: got nil error, want a type-argument inference failure")
+	}
+	if !strings.Contains(err.Error(), "cannot infer type arguments") {
+		t.Fatalf("Generate: err = %v, want it to mention \"cannot infer type arguments\"", err)
+	}
+}
@@ -0,0 +1,20 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package iterseq defines the single- and pair-valued iterator
+// function types used as a |> left-hand side (chunk0-6). They mirror
+// the standard library's iter.Seq/iter.Seq2 (added in Go 1.23), but
+// `for v := range seq` only works on those as of that release; this
+// module targets Go 1.21, so andy-go source calls a Seq/Seq2 directly
+// with a yield func instead, e.g. seq(func(v T) bool { ...; return
+// true }), the same way the |> lowerings in runtime/pipe do.
+package iterseq
+
+// Seq is a single-valued iterator: repeatedly calling yield with each
+// produced value until it returns false.
+type Seq[V any] func(yield func(V) bool)
+
+// Seq2 is a key/value iterator: repeatedly calling yield with each
+// produced pair until it returns false.
+type Seq2[K, V any] func(yield func(K, V) bool)
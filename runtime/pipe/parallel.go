@@ -0,0 +1,77 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pipe provides runtime support for the compiler-generated
+// lowering of the pipe operator family (|>, |?, |/, |||>): element-wise
+// map/filter/reduce over slices, maps, and channels, and the
+// worker-pool lowering of the parallel pipe.
+package pipe
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ParallelMap applies fn to every element of in using workers
+// goroutines, preserving the input order in the returned slice. A
+// workers value <= 0 defaults to runtime.GOMAXPROCS(0). The first
+// panic observed on any worker is re-raised in the calling goroutine
+// only after all workers have finished draining their work.
+func ParallelMap[T, U any](in []T, workers int, fn func(T) U) []U {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if len(in) == 0 {
+		return []U{}
+	}
+	if workers > len(in) {
+		workers = len(in)
+	}
+
+	out := make([]U, len(in))
+
+	type job struct {
+		index int
+		value T
+	}
+	jobs := make(chan job)
+
+	var panicOnce sync.Once
+	var firstPanic any
+
+	done := make(chan struct{}, workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for j := range jobs {
+				// Recover per job, not per worker: a panicking fn must
+				// not take its worker out of the pool, or enough panics
+				// leave no one draining jobs and the dispatch loop below
+				// blocks forever on an unbuffered send.
+				func() {
+					defer func() {
+						if r := recover(); r != nil {
+							panicOnce.Do(func() { firstPanic = r })
+						}
+					}()
+					out[j.index] = fn(j.value)
+				}()
+			}
+		}()
+	}
+
+	for i, v := range in {
+		jobs <- job{index: i, value: v}
+	}
+	close(jobs)
+
+	for w := 0; w < workers; w++ {
+		<-done
+	}
+
+	if firstPanic != nil {
+		panic(firstPanic)
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pipe
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParallelMap(t *testing.T) {
+	in := []int{1, 2, 3, 4, 5}
+	out := ParallelMap(in, 3, func(n int) int { return n * n })
+	want := []int{1, 4, 9, 16, 25}
+	for i, v := range want {
+		if out[i] != v {
+			t.Errorf("out[%d] = %d, want %d", i, out[i], v)
+		}
+	}
+}
+
+func TestParallelMapDefaultWorkers(t *testing.T) {
+	in := []int{1, 2, 3}
+	out := ParallelMap(in, 0, func(n int) int { return n + 1 })
+	if len(out) != len(in) {
+		t.Fatalf("len(out) = %d, want %d", len(out), len(in))
+	}
+	sorted := append([]int(nil), out...)
+	sort.Ints(sorted)
+	want := []int{2, 3, 4}
+	for i, v := range want {
+		if sorted[i] != v {
+			t.Errorf("sorted[%d] = %d, want %d", i, sorted[i], v)
+		}
+	}
+}
+
+func TestParallelMapEmpty(t *testing.T) {
+	out := ParallelMap([]int(nil), 4, func(n int) int { return n })
+	if len(out) != 0 {
+		t.Fatalf("len(out) = %d, want 0", len(out))
+	}
+}
+
+func TestParallelMapPanicPropagates(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic to propagate from a worker")
+		}
+	}()
+	ParallelMap([]int{1, 2, 3}, 2, func(n int) int {
+		if n == 2 {
+			panic("boom")
+		}
+		return n
+	})
+}
+
+// TestParallelMapRunsConcurrently locks in that workers actually run
+// side by side rather than one at a time: every job blocks on a
+// WaitGroup barrier that only releases once all of them have started.
+// A sequential implementation would run the first job, which would
+// then wait forever for the rest to start -- so this deadlocks (and
+// the test times out) instead of merely passing vacuously the way a
+// monotonic counter with no "still running" check would.
+func TestParallelMapRunsConcurrently(t *testing.T) {
+	const n = 5
+	var started sync.WaitGroup
+	started.Add(n)
+	release := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(release)
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ParallelMap(make([]int, n), n, func(int) int {
+			started.Done()
+			<-release
+			return 0
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ParallelMap did not run its jobs concurrently: barrier never released")
+	}
+}
+
+// TestParallelMapAllPanic uses more jobs than workers and panics on
+// every job, so the dispatch loop must keep finding a live worker to
+// drain into well after every worker has recovered from a panic at
+// least once; a recovery path that takes its worker out of the pool
+// would leave the unbuffered jobs send blocked forever.
+func TestParallelMapAllPanic(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic to propagate from a worker")
+			}
+		}()
+		ParallelMap([]int{1, 2, 3, 4, 5, 6, 7, 8}, 2, func(n int) int {
+			panic("boom")
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ParallelMap deadlocked instead of draining after repeated panics")
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pipe
+
+import "github.com/AntiTyping/andy-go/runtime/iterseq"
+
+// Map lowers a slice |> f (f of arity one) into an element-wise
+// transform, preserving order.
+func Map[T, U any](in []T, fn func(T) U) []U {
+	out := make([]U, len(in))
+	for i, v := range in {
+		out[i] = fn(v)
+	}
+	return out
+}
+
+// MapMap lowers a map |> f where f takes only the value (arity one),
+// producing a new map with the same keys and f applied to each value.
+func MapMap[K comparable, V, U any](in map[K]V, fn func(V) U) map[K]U {
+	out := make(map[K]U, len(in))
+	for k, v := range in {
+		out[k] = fn(v)
+	}
+	return out
+}
+
+// MapMap2 lowers a map |> f where f takes both the key and the value
+// (arity two), as detected by the RHS's parameter count.
+func MapMap2[K comparable, V, U any](in map[K]V, fn func(K, V) U) map[K]U {
+	out := make(map[K]U, len(in))
+	for k, v := range in {
+		out[k] = fn(k, v)
+	}
+	return out
+}
+
+// MapChan lowers a <-chan T |> f into a new <-chan U fed by a goroutine
+// that applies fn to every value received from in, closing the
+// returned channel once in is closed (and drained).
+func MapChan[T, U any](in <-chan T, fn func(T) U) <-chan U {
+	out := make(chan U)
+	go func() {
+		defer close(out)
+		for v := range in {
+			out <- fn(v)
+		}
+	}()
+	return out
+}
+
+// MapSeq lowers an iterseq.Seq[T] |> f into an iterseq.Seq[U], applying
+// fn lazily to each value as the result sequence is ranged over.
+func MapSeq[T, U any](in iterseq.Seq[T], fn func(T) U) iterseq.Seq[U] {
+	return func(yield func(U) bool) {
+		in(func(v T) bool {
+			return yield(fn(v))
+		})
+	}
+}
+
+// MapSeq2 lowers an iterseq.Seq2[K, V] |> f into an iterseq.Seq[U]: a
+// two-argument RHS collapses the pair into a single value, so the
+// result is single-valued (see check.Type's PIPE_MAP handling).
+func MapSeq2[K, V, U any](in iterseq.Seq2[K, V], fn func(K, V) U) iterseq.Seq[U] {
+	return func(yield func(U) bool) {
+		in(func(k K, v V) bool {
+			return yield(fn(k, v))
+		})
+	}
+}
+
+// Filter lowers a slice |? f, keeping only the elements for which fn
+// reports true.
+func Filter[T any](in []T, fn func(T) bool) []T {
+	out := make([]T, 0, len(in))
+	for _, v := range in {
+		if fn(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Reduce lowers a slice |/ (seed, fn): fn is applied left to right,
+// starting from seed.
+func Reduce[T, A any](in []T, seed A, fn func(A, T) A) A {
+	acc := seed
+	for _, v := range in {
+		acc = fn(acc, v)
+	}
+	return acc
+}
+
+// ReduceZero lowers a slice |/ fn with no explicit seed: the
+// accumulator starts at T's zero value.
+func ReduceZero[T any](in []T, fn func(T, T) T) T {
+	var acc T
+	for _, v := range in {
+		acc = fn(acc, v)
+	}
+	return acc
+}
@@ -0,0 +1,439 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+// This file defines the AST node set for the subset of Go (plus the
+// pipe operator family) that the andy-go parser accepts. Nodes are
+// printed back out as Go source by package codegen; there is no
+// separate position-preserving pretty-printer, since generated output
+// is not meant to look hand-formatted.
+
+type Node interface {
+	Pos() int
+}
+
+type Expr interface {
+	Node
+	exprNode()
+}
+
+type Stmt interface {
+	Node
+	stmtNode()
+}
+
+type Decl interface {
+	Node
+	declNode()
+}
+
+// File is the root of a parsed source file.
+type File struct {
+	Package int
+	Name    string
+	Imports []*ImportSpec
+	Decls   []Decl
+}
+
+func (f *File) Pos() int { return f.Package }
+
+type ImportSpec struct {
+	TokPos int
+	Alias  string // "" if none
+	Path   string // includes quotes
+}
+
+func (s *ImportSpec) Pos() int { return s.TokPos }
+
+// ---- Types (a restricted type-expression grammar) ----
+
+// TypeExpr is any of: *Ident, *QualifiedIdent, *SliceType, *ArrayType,
+// *MapType, *ChanType, *FuncType, *GenericType, *StructType.
+type TypeExpr interface {
+	Node
+	typeNode()
+}
+
+type Ident struct {
+	NamePos int
+	Name    string
+}
+
+func (x *Ident) Pos() int { return x.NamePos }
+func (*Ident) exprNode()  {}
+func (*Ident) typeNode()  {}
+
+type QualifiedIdent struct {
+	Pkg, Sel *Ident
+}
+
+func (x *QualifiedIdent) Pos() int { return x.Pkg.Pos() }
+func (*QualifiedIdent) exprNode()  {}
+func (*QualifiedIdent) typeNode()  {}
+
+// SliceType also implements exprNode, since make([]T, n) needs to
+// parse []T as an ordinary call argument rather than assuming a
+// composite literal always follows.
+type SliceType struct {
+	Lbrack int
+	Elt    TypeExpr
+}
+
+func (x *SliceType) Pos() int { return x.Lbrack }
+func (*SliceType) typeNode()  {}
+func (*SliceType) exprNode()  {}
+
+type ArrayType struct {
+	Lbrack int
+	Len    Expr // nil for [...]T, otherwise constant length expr
+	Elt    TypeExpr
+}
+
+func (x *ArrayType) Pos() int { return x.Lbrack }
+func (*ArrayType) typeNode()  {}
+
+// MapType also implements exprNode; see SliceType's doc comment.
+type MapType struct {
+	MapPos     int
+	Key, Value TypeExpr
+}
+
+func (x *MapType) Pos() int { return x.MapPos }
+func (*MapType) typeNode()  {}
+func (*MapType) exprNode()  {}
+
+type ChanDir int
+
+const (
+	SendRecv ChanDir = iota
+	RecvOnly         // <-chan T
+	SendOnly         // chan<- T
+)
+
+// ChanType also implements exprNode; see SliceType's doc comment.
+type ChanType struct {
+	ChanPos int
+	Dir     ChanDir
+	Value   TypeExpr
+}
+
+func (x *ChanType) Pos() int { return x.ChanPos }
+func (*ChanType) typeNode()  {}
+func (*ChanType) exprNode()  {}
+
+type EllipsisType struct {
+	Ellipsis int
+	Elt      TypeExpr
+}
+
+func (x *EllipsisType) Pos() int { return x.Ellipsis }
+func (*EllipsisType) typeNode()  {}
+
+// TypeParam is one entry of a [T any] / [K comparable, V any] clause.
+type TypeParam struct {
+	Name       *Ident
+	Constraint TypeExpr
+}
+
+type Field struct {
+	Name *Ident // nil for unnamed params
+	Type TypeExpr
+}
+
+type FuncType struct {
+	FuncPos    int
+	TypeParams []*TypeParam
+	Params     []*Field
+	Results    []*Field
+}
+
+func (x *FuncType) Pos() int { return x.FuncPos }
+func (*FuncType) typeNode()  {}
+
+// GenericType is [Pkg.]Name[Args...], used both for instantiated
+// generic types (Set[int], iterseq.Seq[int]) and generic function
+// instantiation (f[string]) in expression position; the parser
+// disambiguates by context. Pkg is nil except for a qualified type
+// from an imported package.
+type GenericType struct {
+	Pkg  *Ident // nil unless qualified
+	Name *Ident
+	Args []TypeExpr
+}
+
+func (x *GenericType) Pos() int {
+	if x.Pkg != nil {
+		return x.Pkg.Pos()
+	}
+	return x.Name.Pos()
+}
+func (*GenericType) typeNode() {}
+func (*GenericType) exprNode() {}
+
+// StructType also implements exprNode; see SliceType's doc comment --
+// struct{}{} (an empty-struct composite literal, the common set-membership
+// idiom map[T]struct{}{}) needs struct{} to parse as a bare type in
+// expression position the same way []T/map[K]V/chan T do.
+type StructType struct {
+	StructPos int
+	Fields    []*Field
+}
+
+func (x *StructType) Pos() int { return x.StructPos }
+func (*StructType) typeNode()  {}
+func (*StructType) exprNode()  {}
+
+// InterfaceType is the empty interface, `interface{}`, used as Go's
+// any/top type; this fork doesn't parse interface method sets, since
+// nothing in the test suite declares one.
+type InterfaceType struct {
+	InterfacePos int
+}
+
+func (x *InterfaceType) Pos() int { return x.InterfacePos }
+func (*InterfaceType) typeNode()  {}
+
+// ---- Declarations ----
+
+type FuncDecl struct {
+	FuncPos    int
+	Recv       *Field // non-nil for methods
+	Name       *Ident
+	TypeParams []*TypeParam
+	Params     []*Field
+	Results    []*Field
+	Body       *BlockStmt
+}
+
+func (d *FuncDecl) Pos() int { return d.FuncPos }
+func (*FuncDecl) declNode()  {}
+
+type TypeDecl struct {
+	TypePos    int
+	Name       *Ident
+	TypeParams []*TypeParam
+	Type       TypeExpr
+}
+
+func (d *TypeDecl) Pos() int { return d.TypePos }
+func (*TypeDecl) declNode()  {}
+
+type VarDecl struct {
+	VarPos int
+	Names  []*Ident
+	Type   TypeExpr // may be nil if inferred from Values
+	Values []Expr
+}
+
+func (d *VarDecl) Pos() int { return d.VarPos }
+func (*VarDecl) declNode()  {}
+func (*VarDecl) stmtNode()  {}
+
+// ---- Statements ----
+
+type BlockStmt struct {
+	Lbrace int
+	List   []Stmt
+}
+
+func (s *BlockStmt) Pos() int { return s.Lbrace }
+func (*BlockStmt) stmtNode()  {}
+
+type ExprStmt struct {
+	X Expr
+}
+
+func (s *ExprStmt) Pos() int { return s.X.Pos() }
+func (*ExprStmt) stmtNode()  {}
+
+type DeferStmt struct {
+	DeferPos int
+	Call     *CallExpr
+}
+
+func (s *DeferStmt) Pos() int { return s.DeferPos }
+func (*DeferStmt) stmtNode()  {}
+
+type GoStmt struct {
+	GoPos int
+	Call  *CallExpr
+}
+
+func (s *GoStmt) Pos() int { return s.GoPos }
+func (*GoStmt) stmtNode()  {}
+
+// SendStmt is a channel send, `Chan <- Value`.
+type SendStmt struct {
+	Chan     Expr
+	ArrowPos int
+	Value    Expr
+}
+
+func (s *SendStmt) Pos() int { return s.Chan.Pos() }
+func (*SendStmt) stmtNode()  {}
+
+// AssignStmt covers both `=` and `:=`, including multi-value forms.
+type AssignStmt struct {
+	Lhs    []Expr
+	TokPos int
+	Define bool // true for :=
+	Rhs    []Expr
+}
+
+func (s *AssignStmt) Pos() int { return s.TokPos }
+func (*AssignStmt) stmtNode()  {}
+
+// IncDecStmt is `X++` or `X--`, most commonly a for-loop post clause.
+type IncDecStmt struct {
+	X     Expr
+	OpPos int
+	Op    Token // INC or DEC
+}
+
+func (s *IncDecStmt) Pos() int { return s.X.Pos() }
+func (*IncDecStmt) stmtNode()  {}
+
+type ReturnStmt struct {
+	ReturnPos int
+	Results   []Expr
+}
+
+func (s *ReturnStmt) Pos() int { return s.ReturnPos }
+func (*ReturnStmt) stmtNode()  {}
+
+type IfStmt struct {
+	IfPos int
+	Init  Stmt // may be nil
+	Cond  Expr
+	Body  *BlockStmt
+	Else  Stmt // *IfStmt, *BlockStmt, or nil
+}
+
+func (s *IfStmt) Pos() int { return s.IfPos }
+func (*IfStmt) stmtNode()  {}
+
+// ForStmt covers the three-clause, condition-only, and range forms.
+type ForStmt struct {
+	ForPos int
+	Init   Stmt // may be nil
+	Cond   Expr // may be nil
+	Post   Stmt // may be nil
+
+	// Range form: Key/Value/RangeX set, RangeDefine true for `:=`.
+	Key, Value  Expr
+	RangeDefine bool
+	RangeX      Expr
+
+	Body *BlockStmt
+}
+
+func (s *ForStmt) Pos() int { return s.ForPos }
+func (*ForStmt) stmtNode()  {}
+
+// ---- Expressions ----
+
+type BasicLit struct {
+	ValuePos int
+	Kind     Token // INT, FLOAT, STRING
+	Value    string
+}
+
+func (x *BasicLit) Pos() int { return x.ValuePos }
+func (*BasicLit) exprNode()  {}
+
+type FuncLit struct {
+	Type *FuncType
+	Body *BlockStmt
+}
+
+func (x *FuncLit) Pos() int { return x.Type.Pos() }
+func (*FuncLit) exprNode()  {}
+
+type CompositeLit struct {
+	Type TypeExpr // may be nil (elided in nested literals; not used here)
+	Lbrace int
+	Elts []CompositeElt
+}
+
+func (x *CompositeLit) Pos() int { return x.Lbrace }
+func (*CompositeLit) exprNode()  {}
+
+// CompositeElt is either a bare value (Key == nil) or a Key: Value
+// pair, as in map/struct literals.
+type CompositeElt struct {
+	Key   Expr
+	Value Expr
+}
+
+type ParenExpr struct {
+	Lparen int
+	X      Expr
+}
+
+func (x *ParenExpr) Pos() int { return x.Lparen }
+func (*ParenExpr) exprNode()  {}
+
+type SelectorExpr struct {
+	X   Expr
+	Sel *Ident
+}
+
+func (x *SelectorExpr) Pos() int { return x.X.Pos() }
+func (*SelectorExpr) exprNode()  {}
+
+// IndexExpr covers both x[i] and generic instantiation x[T1, T2].
+type IndexExpr struct {
+	X      Expr
+	Lbrack int
+	Index  []Expr
+}
+
+func (x *IndexExpr) Pos() int { return x.X.Pos() }
+func (*IndexExpr) exprNode()  {}
+
+type CallExpr struct {
+	Fun      Expr
+	Lparen   int
+	Args     []Expr
+	Ellipsis bool // trailing `...` on the last argument
+}
+
+func (x *CallExpr) Pos() int { return x.Fun.Pos() }
+func (*CallExpr) exprNode()  {}
+
+type UnaryExpr struct {
+	OpPos int
+	Op    Token
+	X     Expr
+}
+
+func (x *UnaryExpr) Pos() int { return x.OpPos }
+func (*UnaryExpr) exprNode()  {}
+
+type BinaryExpr struct {
+	X     Expr
+	OpPos int
+	Op    Token
+	Y     Expr
+}
+
+func (x *BinaryExpr) Pos() int { return x.X.Pos() }
+func (*BinaryExpr) exprNode()  {}
+
+// PipeExpr is a single stage of a |> / |? / |/ / |||> chain. Seed is
+// non-nil only for PIPE_REDUCE's optional explicit-seed form (`xs |/
+// (seed, f)`). Workers is non-nil only for PIPE_PARALLEL's optional
+// explicit worker-count form (`xs |||> (n) f`).
+type PipeExpr struct {
+	X       Expr
+	OpPos   int
+	Op      Token
+	Seed    Expr
+	Workers Expr
+	Y       Expr
+}
+
+func (x *PipeExpr) Pos() int { return x.X.Pos() }
+func (*PipeExpr) exprNode()  {}
@@ -0,0 +1,76 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import "testing"
+
+// TestMakeSliceArgParsesAsBareType locks in that make([]T, n) parses
+// the []T argument as a bare type, not a composite literal that must
+// be followed by {.
+func TestMakeSliceArgParsesAsBareType(t *testing.T) {
+	x := parseExprString(t, "make([]int, 0, 3)")
+	call, ok := x.(*CallExpr)
+	if !ok || len(call.Args) != 3 {
+		t.Fatalf("got %#v, want *CallExpr with 3 args", x)
+	}
+	if _, ok := call.Args[0].(*SliceType); !ok {
+		t.Fatalf("call.Args[0] = %#v, want *SliceType", call.Args[0])
+	}
+}
+
+// TestMakeMapArgParsesAsBareType locks in the same for make(map[K]V).
+func TestMakeMapArgParsesAsBareType(t *testing.T) {
+	x := parseExprString(t, "make(map[string]int)")
+	call, ok := x.(*CallExpr)
+	if !ok || len(call.Args) != 1 {
+		t.Fatalf("got %#v, want *CallExpr with 1 arg", x)
+	}
+	if _, ok := call.Args[0].(*MapType); !ok {
+		t.Fatalf("call.Args[0] = %#v, want *MapType", call.Args[0])
+	}
+}
+
+// TestSliceCompositeLitStillParses locks in that a composite literal
+// still works once the type is immediately followed by {, the case
+// make's bare-type argument must be distinguished from.
+func TestSliceCompositeLitStillParses(t *testing.T) {
+	x := parseExprString(t, "[]int{1, 2, 3}")
+	lit, ok := x.(*CompositeLit)
+	if !ok || len(lit.Elts) != 3 {
+		t.Fatalf("got %#v, want *CompositeLit with 3 elements", x)
+	}
+}
+
+// TestIncDecStmtParses locks in that i++ and i-- parse as IncDecStmt,
+// most commonly seen as a for-loop post clause (e.g. for i := 0; i <
+// n; i++), rather than failing as an unsupported simple statement.
+func TestIncDecStmtParses(t *testing.T) {
+	f, err := Parse([]byte("package p\nfunc f() {\ni := 0\ni++\ni--\n}\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	fd := f.Decls[0].(*FuncDecl)
+	inc, ok := fd.Body.List[1].(*IncDecStmt)
+	if !ok || inc.Op != INC {
+		t.Fatalf("List[1] = %#v, want *IncDecStmt{Op: INC}", fd.Body.List[1])
+	}
+	dec, ok := fd.Body.List[2].(*IncDecStmt)
+	if !ok || dec.Op != DEC {
+		t.Fatalf("List[2] = %#v, want *IncDecStmt{Op: DEC}", fd.Body.List[2])
+	}
+}
+
+// TestEmptyInterfaceType locks in that interface{} parses as a type,
+// usable as a parameter/variable type (the "any" top type).
+func TestEmptyInterfaceType(t *testing.T) {
+	f, err := Parse([]byte("package p\nfunc f(x interface{}) {\n}\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	fd := f.Decls[0].(*FuncDecl)
+	if _, ok := fd.Params[0].Type.(*InterfaceType); !ok {
+		t.Fatalf("param type = %#v, want *InterfaceType", fd.Params[0].Type)
+	}
+}
@@ -0,0 +1,953 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import "fmt"
+
+// ParseError is returned by Parse on a syntax error.
+type ParseError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("offset %d: %s", e.Offset, e.Msg)
+}
+
+type parser struct {
+	scanner *Scanner
+	pos     int
+	tok     Token
+	lit     string
+
+	// exprLev tracks whether `{` after a primary expression may start a
+	// composite literal: >= 0 in ordinary expression context, < 0 while
+	// parsing an if/for header, where a bare `{` instead opens the
+	// statement body (the same ambiguity go/parser resolves for `if`,
+	// `for`, and `switch`). Parsing inside `(...)` or `[...]` resets it
+	// to 0 so a composite literal nested in a call or index expression
+	// — even one appearing in a header, e.g. `if f(T{x}) { ... }` — is
+	// still recognized.
+	exprLev int
+}
+
+// Parse parses a complete andy-go source file.
+func Parse(src []byte) (f *File, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if se, ok := r.(*ScanError); ok {
+				err = &ParseError{Offset: se.Offset, Msg: se.Msg}
+				return
+			}
+			if pe, ok := r.(*ParseError); ok {
+				err = pe
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	p := &parser{scanner: NewScanner(src)}
+	p.next()
+	return p.parseFile(), nil
+}
+
+func (p *parser) next() {
+	p.pos, p.tok, p.lit = p.scanner.Scan()
+}
+
+func (p *parser) errorf(format string, args ...any) {
+	panic(&ParseError{Offset: p.pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) expect(tok Token) (pos int, lit string) {
+	if p.tok != tok {
+		p.errorf("expected %s, found %q", tok, p.lit)
+	}
+	pos, lit = p.pos, p.lit
+	p.next()
+	return
+}
+
+// expectSemi consumes a statement-terminating semicolon, real or
+// automatically inserted, and tolerates a following closing brace (a
+// trailing semicolon before `}` is optional, as in Go).
+func (p *parser) expectSemi() {
+	if p.tok == RBRACE {
+		return
+	}
+	if p.tok != SEMICOLON {
+		p.errorf("expected ;, found %q", p.lit)
+	}
+	p.next()
+}
+
+func (p *parser) parseIdent() *Ident {
+	pos, lit := p.expect(IDENT)
+	return &Ident{NamePos: pos, Name: lit}
+}
+
+// ---- File-level grammar ----
+
+func (p *parser) parseFile() *File {
+	pos, _ := p.expect(PACKAGE)
+	name := p.parseIdent().Name
+	p.expectSemi()
+
+	f := &File{Package: pos, Name: name}
+
+	for p.tok == IMPORT {
+		f.Imports = append(f.Imports, p.parseImportDecl()...)
+		p.expectSemi()
+	}
+
+	for p.tok != EOF {
+		f.Decls = append(f.Decls, p.parseTopLevelDecl())
+		p.expectSemi()
+	}
+	return f
+}
+
+func (p *parser) parseImportDecl() []*ImportSpec {
+	p.next() // "import"
+	if p.tok == LPAREN {
+		p.next()
+		var specs []*ImportSpec
+		for p.tok != RPAREN {
+			specs = append(specs, p.parseImportSpec())
+			p.expectSemi()
+		}
+		p.next() // ")"
+		return specs
+	}
+	return []*ImportSpec{p.parseImportSpec()}
+}
+
+func (p *parser) parseImportSpec() *ImportSpec {
+	spec := &ImportSpec{TokPos: p.pos}
+	if p.tok == IDENT {
+		spec.Alias = p.lit
+		p.next()
+	}
+	pos, lit := p.expect(STRING)
+	spec.TokPos = pos
+	spec.Path = lit
+	return spec
+}
+
+func (p *parser) parseTopLevelDecl() Decl {
+	switch p.tok {
+	case FUNC:
+		return p.parseFuncDecl()
+	case TYPE:
+		return p.parseTypeDecl()
+	case VAR:
+		return p.parseVarDecl()
+	}
+	p.errorf("expected declaration, found %q", p.lit)
+	return nil
+}
+
+// ---- Types ----
+
+func (p *parser) parseType() TypeExpr {
+	switch p.tok {
+	case MUL: // unused presently, reserved
+		p.errorf("pointer types are not supported")
+	case LBRACK:
+		lbrack := p.pos
+		p.next()
+		if p.tok == RBRACK {
+			p.next()
+			return &SliceType{Lbrack: lbrack, Elt: p.parseType()}
+		}
+		length := p.parseExpr()
+		p.expect(RBRACK)
+		return &ArrayType{Lbrack: lbrack, Len: length, Elt: p.parseType()}
+	case MAP:
+		mapPos := p.pos
+		p.next()
+		p.expect(LBRACK)
+		key := p.parseType()
+		p.expect(RBRACK)
+		return &MapType{MapPos: mapPos, Key: key, Value: p.parseType()}
+	case ARROW:
+		chanPos := p.pos
+		p.next()
+		p.expect(CHAN)
+		return &ChanType{ChanPos: chanPos, Dir: RecvOnly, Value: p.parseType()}
+	}
+	if p.tok == CHAN {
+		chanPos := p.pos
+		p.next()
+		if p.tok == ARROW {
+			p.next()
+			return &ChanType{ChanPos: chanPos, Dir: SendOnly, Value: p.parseType()}
+		}
+		return &ChanType{ChanPos: chanPos, Dir: SendRecv, Value: p.parseType()}
+	}
+	if p.tok == FUNC {
+		return p.parseFuncType()
+	}
+	if p.tok == STRUCT {
+		return p.parseStructType()
+	}
+	if p.tok == INTERFACE {
+		pos := p.pos
+		p.next()
+		p.expect(LBRACE)
+		p.expect(RBRACE)
+		return &InterfaceType{InterfacePos: pos}
+	}
+	if p.tok == ELLIPSIS {
+		pos := p.pos
+		p.next()
+		return &EllipsisType{Ellipsis: pos, Elt: p.parseType()}
+	}
+
+	name := p.parseIdent()
+	var base TypeExpr = name
+	if p.tok == PERIOD {
+		p.next()
+		base = &QualifiedIdent{Pkg: name, Sel: p.parseIdent()}
+	}
+	if p.tok == LBRACK {
+		p.next()
+		var args []TypeExpr
+		for {
+			args = append(args, p.parseType())
+			if p.tok != COMMA {
+				break
+			}
+			p.next()
+		}
+		p.expect(RBRACK)
+		var pkg, ident *Ident
+		switch b := base.(type) {
+		case *Ident:
+			ident = b
+		case *QualifiedIdent:
+			pkg, ident = b.Pkg, b.Sel
+		default:
+			p.errorf("generic instantiation requires a simple or qualified name")
+		}
+		return &GenericType{Pkg: pkg, Name: ident, Args: args}
+	}
+	return base
+}
+
+func (p *parser) parseFuncType() *FuncType {
+	pos := p.pos
+	p.next() // "func"
+	ft := &FuncType{FuncPos: pos}
+	ft.Params, _ = p.parseParams()
+	ft.Results = p.parseResults()
+	return ft
+}
+
+func (p *parser) parseStructType() *StructType {
+	pos := p.pos
+	p.next() // "struct"
+	p.expect(LBRACE)
+	st := &StructType{StructPos: pos}
+	for p.tok != RBRACE {
+		names := []*Ident{p.parseIdent()}
+		for p.tok == COMMA {
+			p.next()
+			names = append(names, p.parseIdent())
+		}
+		typ := p.parseType()
+		for _, n := range names {
+			st.Fields = append(st.Fields, &Field{Name: n, Type: typ})
+		}
+		p.expectSemi()
+	}
+	p.next() // "}"
+	return st
+}
+
+// parseTypeParams parses a `[T any, U comparable]`-style clause.
+func (p *parser) parseTypeParams() []*TypeParam {
+	if p.tok != LBRACK {
+		return nil
+	}
+	p.next()
+	var params []*TypeParam
+	for p.tok != RBRACK {
+		names := []*Ident{p.parseIdent()}
+		for p.tok == COMMA {
+			// could be another name sharing a constraint, or the next param;
+			// look ahead isn't available, so require same-constraint grouping
+			// only when followed by an identifier then a non-comma constraint.
+			p.next()
+			names = append(names, p.parseIdent())
+		}
+		constraint := p.parseType()
+		for _, n := range names {
+			params = append(params, &TypeParam{Name: n, Constraint: constraint})
+		}
+		if p.tok == COMMA {
+			p.next()
+		}
+	}
+	p.next() // "]"
+	return params
+}
+
+// parseParams parses a parenthesized, possibly-grouped parameter list.
+func (p *parser) parseParams() ([]*Field, int) {
+	lparen := p.pos
+	p.expect(LPAREN)
+	var fields []*Field
+	for p.tok != RPAREN {
+		fields = append(fields, p.parseParamGroup()...)
+		if p.tok == COMMA {
+			p.next()
+		}
+	}
+	p.next() // ")"
+	return fields, lparen
+}
+
+// parseParamGroup parses one comma-free chunk of a parameter list: a
+// run of names sharing a type (`a, b int`), a single named parameter,
+// or a single unnamed type.
+func (p *parser) parseParamGroup() []*Field {
+	if p.tok == IDENT {
+		start := p.pos
+		name := p.lit
+		savedScanner := *p.scanner
+		savedTok, savedPos, savedLit := p.tok, p.pos, p.lit
+		p.next()
+		if p.tok == COMMA || isTypeStart(p.tok) {
+			// name followed by a type (or another name): named parameter(s)
+			names := []*Ident{{NamePos: start, Name: name}}
+			for p.tok == COMMA {
+				p.next()
+				names = append(names, p.parseIdent())
+			}
+			if !isTypeStart(p.tok) {
+				// No shared type follows the name list after all -- this
+				// is a result list of several unnamed types with no
+				// parameter names, e.g. `(T, U)` returning two distinct
+				// generic type parameters. Reinterpret each identifier
+				// collected above as its own unnamed type.
+				var fields []*Field
+				for _, n := range names {
+					fields = append(fields, &Field{Type: n})
+				}
+				return fields
+			}
+			typ := p.parseType()
+			var fields []*Field
+			for _, n := range names {
+				fields = append(fields, &Field{Name: n, Type: typ})
+			}
+			return fields
+		}
+		// not a named parameter after all: rewind and parse as a bare type
+		*p.scanner = savedScanner
+		p.tok, p.pos, p.lit = savedTok, savedPos, savedLit
+	}
+	return []*Field{{Type: p.parseType()}}
+}
+
+func isTypeStart(t Token) bool {
+	switch t {
+	case IDENT, LBRACK, MUL, MAP, CHAN, ARROW, FUNC, STRUCT, INTERFACE, ELLIPSIS:
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseResults() []*Field {
+	switch p.tok {
+	case LPAREN:
+		fields, _ := p.parseParams()
+		return fields
+	case SEMICOLON, RBRACE, RPAREN, COMMA, EOF, LBRACE:
+		return nil
+	default:
+		return []*Field{{Type: p.parseType()}}
+	}
+}
+
+// ---- Declarations ----
+
+func (p *parser) parseFuncDecl() *FuncDecl {
+	pos := p.pos
+	p.next() // "func"
+
+	d := &FuncDecl{FuncPos: pos}
+	if p.tok == LPAREN {
+		fields, _ := p.parseParams()
+		if len(fields) != 1 {
+			p.errorf("method receiver must have exactly one parameter")
+		}
+		d.Recv = fields[0]
+	}
+
+	d.Name = p.parseIdent()
+	d.TypeParams = p.parseTypeParams()
+	d.Params, _ = p.parseParams()
+	d.Results = p.parseResults()
+	d.Body = p.parseBlock()
+	return d
+}
+
+func (p *parser) parseTypeDecl() *TypeDecl {
+	pos := p.pos
+	p.next() // "type"
+	d := &TypeDecl{TypePos: pos}
+	d.Name = p.parseIdent()
+	d.TypeParams = p.parseTypeParams()
+	d.Type = p.parseType()
+	return d
+}
+
+func (p *parser) parseVarDecl() *VarDecl {
+	pos := p.pos
+	p.next() // "var"
+	return p.parseVarSpec(pos)
+}
+
+func (p *parser) parseVarSpec(pos int) *VarDecl {
+	d := &VarDecl{VarPos: pos}
+	d.Names = append(d.Names, p.parseIdent())
+	for p.tok == COMMA {
+		p.next()
+		d.Names = append(d.Names, p.parseIdent())
+	}
+	if p.tok != ASSIGN {
+		d.Type = p.parseType()
+	}
+	if p.tok == ASSIGN {
+		p.next()
+		d.Values = append(d.Values, p.parseExpr())
+		for p.tok == COMMA {
+			p.next()
+			d.Values = append(d.Values, p.parseExpr())
+		}
+	}
+	return d
+}
+
+// ---- Statements ----
+
+func (p *parser) parseBlock() *BlockStmt {
+	lbrace, _ := p.expect(LBRACE)
+	b := &BlockStmt{Lbrace: lbrace}
+	for p.tok != RBRACE {
+		b.List = append(b.List, p.parseStmt())
+		p.expectSemi()
+	}
+	p.next() // "}"
+	return b
+}
+
+func (p *parser) parseStmt() Stmt {
+	switch p.tok {
+	case VAR:
+		pos := p.pos
+		p.next()
+		return p.parseVarSpec(pos)
+	case RETURN:
+		pos := p.pos
+		p.next()
+		r := &ReturnStmt{ReturnPos: pos}
+		if p.tok != SEMICOLON && p.tok != RBRACE {
+			r.Results = append(r.Results, p.parseExpr())
+			for p.tok == COMMA {
+				p.next()
+				r.Results = append(r.Results, p.parseExpr())
+			}
+		}
+		return r
+	case IF:
+		return p.parseIfStmt()
+	case FOR:
+		return p.parseForStmt()
+	case DEFER:
+		pos := p.pos
+		p.next()
+		call := p.parseExpr()
+		ce, ok := call.(*CallExpr)
+		if !ok {
+			p.errorf("expected call expression after defer")
+		}
+		return &DeferStmt{DeferPos: pos, Call: ce}
+	case GO:
+		pos := p.pos
+		p.next()
+		call := p.parseExpr()
+		ce, ok := call.(*CallExpr)
+		if !ok {
+			p.errorf("expected call expression after go")
+		}
+		return &GoStmt{GoPos: pos, Call: ce}
+	case LBRACE:
+		return p.parseBlock()
+	default:
+		return p.parseSimpleStmt()
+	}
+}
+
+func (p *parser) parseSimpleStmt() Stmt {
+	lhs := []Expr{p.parseExpr()}
+	for p.tok == COMMA {
+		p.next()
+		lhs = append(lhs, p.parseExpr())
+	}
+
+	switch p.tok {
+	case DEFINE, ASSIGN:
+		define := p.tok == DEFINE
+		tokPos := p.pos
+		p.next()
+		rhs := []Expr{p.parseExpr()}
+		for p.tok == COMMA {
+			p.next()
+			rhs = append(rhs, p.parseExpr())
+		}
+		return &AssignStmt{Lhs: lhs, TokPos: tokPos, Define: define, Rhs: rhs}
+	case ARROW:
+		if len(lhs) != 1 {
+			p.errorf("expected a single channel operand before <-")
+		}
+		arrowPos := p.pos
+		p.next()
+		return &SendStmt{Chan: lhs[0], ArrowPos: arrowPos, Value: p.parseExpr()}
+	case INC, DEC:
+		if len(lhs) != 1 {
+			p.errorf("expected a single operand before %s", p.tok)
+		}
+		opPos, op := p.pos, p.tok
+		p.next()
+		return &IncDecStmt{X: lhs[0], OpPos: opPos, Op: op}
+	}
+
+	if len(lhs) != 1 {
+		p.errorf("expected := or = after expression list")
+	}
+	return &ExprStmt{X: lhs[0]}
+}
+
+func (p *parser) parseIfStmt() *IfStmt {
+	pos := p.pos
+	p.next() // "if"
+	s := &IfStmt{IfPos: pos}
+
+	outer := p.exprLev
+	p.exprLev = -1
+	init, cond := p.parseSimpleStmtOrCond()
+	p.exprLev = outer
+	s.Init, s.Cond = init, cond
+	s.Body = p.parseBlock()
+	if p.tok == ELSE {
+		p.next()
+		if p.tok == IF {
+			s.Else = p.parseIfStmt()
+		} else {
+			s.Else = p.parseBlock()
+		}
+	}
+	return s
+}
+
+// parseSimpleStmtOrCond parses `[SimpleStmt ";"] Expr`, as used by if
+// and the 3-clause for header, disambiguated by a following ";".
+func (p *parser) parseSimpleStmtOrCond() (init Stmt, cond Expr) {
+	first := p.parseExpr()
+	if p.tok == SEMICOLON {
+		// `first` was actually a bare condition with no init.
+		return nil, first
+	}
+	if p.tok == DEFINE || p.tok == ASSIGN {
+		define := p.tok == DEFINE
+		tokPos := p.pos
+		p.next()
+		rhs := []Expr{p.parseExpr()}
+		for p.tok == COMMA {
+			p.next()
+			rhs = append(rhs, p.parseExpr())
+		}
+		init = &AssignStmt{Lhs: []Expr{first}, TokPos: tokPos, Define: define, Rhs: rhs}
+		p.expect(SEMICOLON)
+		return init, p.parseExpr()
+	}
+	return nil, first
+}
+
+func (p *parser) parseForStmt() *ForStmt {
+	pos := p.pos
+	p.next() // "for"
+	s := &ForStmt{ForPos: pos}
+
+	if p.tok == LBRACE {
+		s.Body = p.parseBlock()
+		return s
+	}
+
+	outer := p.exprLev
+	p.exprLev = -1
+	defer func() { p.exprLev = outer }()
+
+	// Try range form: for [k [, v]] := range X { ... } or for range X.
+	if p.tok == RANGE {
+		p.next()
+		s.RangeX = p.parseExpr()
+		p.exprLev = outer
+		s.Body = p.parseBlock()
+		return s
+	}
+
+	// Peek for `IDENT [, IDENT] := range` by speculatively parsing an
+	// expression list and checking what follows.
+	savedScanner := *p.scanner
+	savedTok, savedPos, savedLit := p.tok, p.pos, p.lit
+
+	first := p.parseExpr()
+	if p.tok == COMMA || p.tok == DEFINE {
+		var key, value Expr = first, nil
+		if p.tok == COMMA {
+			p.next()
+			value = p.parseExpr()
+		}
+		if p.tok == DEFINE {
+			p.next()
+			if p.tok == RANGE {
+				p.next()
+				s.Key, s.Value, s.RangeDefine = key, value, true
+				s.RangeX = p.parseExpr()
+				p.exprLev = outer
+				s.Body = p.parseBlock()
+				return s
+			}
+		}
+	}
+
+	// Not a range form: rewind and parse the general 3-clause/cond form.
+	*p.scanner = savedScanner
+	p.tok, p.pos, p.lit = savedTok, savedPos, savedLit
+
+	if p.tok == SEMICOLON {
+		// Empty init clause: `for ; cond; post { ... }`.
+		p.next()
+		if p.tok != SEMICOLON {
+			s.Cond = p.parseExpr()
+		}
+		p.expect(SEMICOLON)
+	} else {
+		init, cond := p.parseSimpleStmtOrCond()
+		if cond != nil && init == nil && p.tok == LBRACE {
+			// A bare condition directly followed by `{`: the
+			// single-condition form `for cond { ... }`.
+			s.Cond = cond
+			p.exprLev = outer
+			s.Body = p.parseBlock()
+			return s
+		}
+		s.Init = init
+		s.Cond = cond
+		p.expect(SEMICOLON)
+	}
+	if p.tok != LBRACE {
+		s.Post = p.parseSimpleStmt()
+	}
+	p.exprLev = outer
+	s.Body = p.parseBlock()
+	return s
+}
+
+// ---- Expressions ----
+
+func (p *parser) parseExpr() Expr {
+	return p.parseBinaryExpr(LowestPrec + 1)
+}
+
+func (p *parser) parseBinaryExpr(minPrec int) Expr {
+	x := p.parseUnaryExpr()
+	for {
+		prec := Precedence(p.tok)
+		if prec < minPrec {
+			return x
+		}
+		op, opPos := p.tok, p.pos
+		p.next()
+
+		if op == PIPE_REDUCE {
+			x = p.parsePipeReduce(x, opPos)
+			continue
+		}
+		if op == PIPE_PARALLEL {
+			x = p.parsePipeParallel(x, opPos)
+			continue
+		}
+		y := p.parseBinaryExpr(prec + 1)
+		if IsPipeOp(op) {
+			x = &PipeExpr{X: x, OpPos: opPos, Op: op, Y: y}
+		} else {
+			x = &BinaryExpr{X: x, OpPos: opPos, Op: op, Y: y}
+		}
+	}
+}
+
+// parsePipeReduce parses the RHS of |/, either a bare accumulator
+// function or an explicit `(seed, fn)` pair.
+func (p *parser) parsePipeReduce(x Expr, opPos int) Expr {
+	pe := &PipeExpr{X: x, OpPos: opPos, Op: PIPE_REDUCE}
+	if p.tok == LPAREN {
+		savedScanner := *p.scanner
+		savedTok, savedPos, savedLit := p.tok, p.pos, p.lit
+		p.next()
+		seed := p.parseExpr()
+		if p.tok == COMMA {
+			p.next()
+			fn := p.parseExpr()
+			p.expect(RPAREN)
+			pe.Seed = seed
+			pe.Y = fn
+			return pe
+		}
+		// Not the (seed, fn) form after all (e.g. a parenthesized
+		// function expression): rewind and fall through.
+		*p.scanner = savedScanner
+		p.tok, p.pos, p.lit = savedTok, savedPos, savedLit
+	}
+	pe.Y = p.parseBinaryExpr(Precedence(PIPE_REDUCE) + 1)
+	return pe
+}
+
+// parsePipeParallel parses the RHS of |||>, which optionally starts
+// with a parenthesized worker-count expression: `xs |||> (n) f`.
+func (p *parser) parsePipeParallel(x Expr, opPos int) Expr {
+	pe := &PipeExpr{X: x, OpPos: opPos, Op: PIPE_PARALLEL}
+	if p.tok == LPAREN {
+		p.next()
+		pe.Workers = p.parseExpr()
+		p.expect(RPAREN)
+	}
+	pe.Y = p.parseBinaryExpr(Precedence(PIPE_PARALLEL) + 1)
+	return pe
+}
+
+func (p *parser) parseUnaryExpr() Expr {
+	switch p.tok {
+	case ADD, SUB, NOT, XOR, AND, ARROW:
+		pos, op := p.pos, p.tok
+		p.next()
+		return &UnaryExpr{OpPos: pos, Op: op, X: p.parseUnaryExpr()}
+	}
+	return p.parsePrimaryExpr()
+}
+
+func (p *parser) parsePrimaryExpr() Expr {
+	x := p.parseOperand()
+	for {
+		switch p.tok {
+		case PERIOD:
+			p.next()
+			x = &SelectorExpr{X: x, Sel: p.parseIdent()}
+		case LBRACK:
+			lbrack := p.pos
+			p.next()
+			oldLev := p.exprLev
+			p.exprLev = 0
+			var idx []Expr
+			idx = append(idx, p.parseExpr())
+			for p.tok == COMMA {
+				p.next()
+				idx = append(idx, p.parseExpr())
+			}
+			p.exprLev = oldLev
+			p.expect(RBRACK)
+			x = &IndexExpr{X: x, Lbrack: lbrack, Index: idx}
+		case LPAREN:
+			x = p.finishCall(x)
+		case LBRACE:
+			if p.exprLev < 0 || !p.canStartCompositeLit(x) {
+				return x
+			}
+			x = p.finishCompositeLit(x, p.pos)
+		default:
+			return x
+		}
+	}
+}
+
+// canStartCompositeLit reports whether x (already-parsed as a type
+// name, generic instantiation, or similar) may be followed directly
+// by a composite literal brace. Struct/named types may; the caller in
+// parsePrimaryExpr additionally checks p.exprLev, which is < 0 while
+// parsing an if/for header, so a bare `T{` there is left for the
+// header's own `{` rather than misread as a literal — the same
+// disambiguation go/parser applies, parenthesize the literal to opt
+// back in.
+func (p *parser) canStartCompositeLit(x Expr) bool {
+	switch x.(type) {
+	case *Ident, *SelectorExpr, *GenericType, *IndexExpr:
+		return true
+	}
+	return false
+}
+
+func (p *parser) finishCall(fun Expr) *CallExpr {
+	lparen := p.pos
+	p.next() // "("
+	oldLev := p.exprLev
+	p.exprLev = 0
+	call := &CallExpr{Fun: fun, Lparen: lparen}
+	for p.tok != RPAREN {
+		call.Args = append(call.Args, p.parseExpr())
+		if p.tok == ELLIPSIS {
+			p.next()
+			call.Ellipsis = true
+		}
+		if p.tok != COMMA {
+			break
+		}
+		p.next()
+	}
+	p.exprLev = oldLev
+	p.expect(RPAREN)
+	return call
+}
+
+func (p *parser) finishCompositeLit(typ Expr, lbrace int) *CompositeLit {
+	p.next() // "{"
+	lit := &CompositeLit{Type: typExprFrom(typ), Lbrace: lbrace}
+	for p.tok != RBRACE {
+		elt := p.parseCompositeElt()
+		lit.Elts = append(lit.Elts, elt)
+		if p.tok != COMMA {
+			break
+		}
+		p.next()
+	}
+	p.expect(RBRACE)
+	return lit
+}
+
+// typExprFrom converts an already-parsed expression standing in a
+// composite literal's type position back into a TypeExpr. Most cases
+// parsed as a type to begin with (*Ident, *GenericType, ...), but a
+// generic instantiation like Box[int] in expression position comes
+// back from parsePrimaryExpr as an *IndexExpr (the same node used for
+// plain indexing, x[i]) since the parser doesn't know which it is
+// until it sees the following {. Rebuild the *GenericType that
+// parseType would have produced for the same source.
+func typExprFrom(x Expr) TypeExpr {
+	if ix, ok := x.(*IndexExpr); ok {
+		var pkg, name *Ident
+		switch base := ix.X.(type) {
+		case *Ident:
+			name = base
+		case *SelectorExpr:
+			if p, ok := base.X.(*Ident); ok {
+				pkg, name = p, base.Sel
+			}
+		}
+		if name == nil {
+			return nil
+		}
+		args := make([]TypeExpr, len(ix.Index))
+		for i, idx := range ix.Index {
+			t, ok := idx.(TypeExpr)
+			if !ok {
+				return nil
+			}
+			args[i] = t
+		}
+		return &GenericType{Pkg: pkg, Name: name, Args: args}
+	}
+	t, _ := x.(TypeExpr)
+	return t
+}
+
+func (p *parser) parseCompositeElt() CompositeElt {
+	first := p.parseElement()
+	if p.tok == COLON {
+		p.next()
+		return CompositeElt{Key: first, Value: p.parseElement()}
+	}
+	return CompositeElt{Value: first}
+}
+
+// parseElement parses one composite-literal key or value, which may
+// additionally be an elided-type nested literal (a bare `{...}`, e.g.
+// the {1, 5} elements of [][2]int{{1, 5}, {9, 3}}) -- a form that's
+// only valid directly inside an enclosing composite literal, so it's
+// handled here rather than as a general parseOperand case.
+func (p *parser) parseElement() Expr {
+	if p.tok == LBRACE {
+		p.next()
+		return p.finishCompositeLitAfterType(nil)
+	}
+	return p.parseExpr()
+}
+
+func (p *parser) parseOperand() Expr {
+	switch p.tok {
+	case IDENT:
+		id := p.parseIdent()
+		return id
+	case INT, FLOAT, STRING:
+		lit := &BasicLit{ValuePos: p.pos, Kind: p.tok, Value: p.lit}
+		p.next()
+		return lit
+	case LPAREN:
+		lparen := p.pos
+		p.next()
+		oldLev := p.exprLev
+		p.exprLev = 0
+		x := p.parseExpr()
+		p.exprLev = oldLev
+		p.expect(RPAREN)
+		return &ParenExpr{Lparen: lparen, X: x}
+	case FUNC:
+		ft := p.parseFuncType()
+		body := p.parseBlock()
+		return &FuncLit{Type: ft, Body: body}
+	case LBRACK, MAP, CHAN, STRUCT:
+		return p.parseTypeOperand()
+	}
+	p.errorf("expected operand, found %q", p.lit)
+	return nil
+}
+
+// parseTypeOperand parses a []T, map[K]V, chan T, or struct{...} type
+// appearing in expression position: as a bare type argument to a
+// builtin like make([]T, n), make(map[K]V), or make(chan T), or
+// immediately followed by `{` as a composite literal (the common case
+// for struct{}{}; a chan type has no literal form). p.tok must be
+// LBRACK, MAP, CHAN, or STRUCT on entry.
+func (p *parser) parseTypeOperand() Expr {
+	typ := p.parseType()
+	if p.tok == LBRACE {
+		p.next()
+		return p.finishCompositeLitAfterType(typ)
+	}
+	x, ok := typ.(Expr)
+	if !ok {
+		p.errorf("type %T cannot be used as an expression", typ)
+		return nil
+	}
+	return x
+}
+
+func (p *parser) finishCompositeLitAfterType(typ TypeExpr) *CompositeLit {
+	lbrace := p.pos
+	lit := &CompositeLit{Type: typ, Lbrace: lbrace}
+	for p.tok != RBRACE {
+		elt := p.parseCompositeElt()
+		lit.Elts = append(lit.Elts, elt)
+		if p.tok != COMMA {
+			break
+		}
+		p.next()
+	}
+	p.expect(RBRACE)
+	return lit
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import "testing"
+
+// parseExprString parses src as the sole statement of a minimal
+// function body and returns the resulting expression, for asserting
+// on its parse tree shape directly (as opposed to test/pipe_precedence.go,
+// which only checks the runtime values a correctly-shaped tree would
+// produce).
+func parseExprString(t *testing.T, src string) Expr {
+	t.Helper()
+	f, err := Parse([]byte("package p\nfunc f() {\n" + src + "\n}\n"))
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", src, err)
+	}
+	fd, ok := f.Decls[0].(*FuncDecl)
+	if !ok || len(fd.Body.List) != 1 {
+		t.Fatalf("Parse(%q): expected a single statement, got %#v", src, f.Decls[0])
+	}
+	es, ok := fd.Body.List[0].(*ExprStmt)
+	if !ok {
+		t.Fatalf("Parse(%q): expected an expression statement, got %#v", src, fd.Body.List[0])
+	}
+	return es.X
+}
+
+// TestPrecedencePlusBindsTighterThanPipe locks in that a + b |> f
+// parses as (a + b) |> f, not a + (b |> f).
+func TestPrecedencePlusBindsTighterThanPipe(t *testing.T) {
+	x := parseExprString(t, "a + b |> f")
+	pe, ok := x.(*PipeExpr)
+	if !ok {
+		t.Fatalf("got %#v, want *PipeExpr", x)
+	}
+	if pe.Op != PIPE_MAP {
+		t.Fatalf("pe.Op = %v, want PIPE_MAP", pe.Op)
+	}
+	if _, ok := pe.X.(*BinaryExpr); !ok {
+		t.Fatalf("pe.X = %#v, want *BinaryExpr (a + b)", pe.X)
+	}
+	if _, ok := pe.Y.(*Ident); !ok {
+		t.Fatalf("pe.Y = %#v, want *Ident (f)", pe.Y)
+	}
+}
+
+// TestPrecedenceLeftAssociative locks in that xs |> f |> g parses as
+// (xs |> f) |> g.
+func TestPrecedenceLeftAssociative(t *testing.T) {
+	x := parseExprString(t, "xs |> f |> g")
+	outer, ok := x.(*PipeExpr)
+	if !ok {
+		t.Fatalf("got %#v, want *PipeExpr", x)
+	}
+	if _, ok := outer.Y.(*Ident); !ok {
+		t.Fatalf("outer.Y = %#v, want *Ident (g)", outer.Y)
+	}
+	inner, ok := outer.X.(*PipeExpr)
+	if !ok {
+		t.Fatalf("outer.X = %#v, want *PipeExpr (xs |> f)", outer.X)
+	}
+	if id, ok := inner.X.(*Ident); !ok || id.Name != "xs" {
+		t.Fatalf("inner.X = %#v, want *Ident(xs)", inner.X)
+	}
+}
+
+// TestPrecedenceLogicalOrBindsTighterThanPipe locks in that
+// cond || xs |> f parses as (cond || xs) |> f, not cond || (xs |> f):
+// the pipe family sits below ||, so || binds its operands tighter.
+func TestPrecedenceLogicalOrBindsTighterThanPipe(t *testing.T) {
+	x := parseExprString(t, "cond || xs |> f")
+	pe, ok := x.(*PipeExpr)
+	if !ok {
+		t.Fatalf("got %#v, want *PipeExpr", x)
+	}
+	if pe.Op != PIPE_MAP {
+		t.Fatalf("pe.Op = %v, want PIPE_MAP", pe.Op)
+	}
+	if _, ok := pe.X.(*BinaryExpr); !ok {
+		t.Fatalf("pe.X = %#v, want *BinaryExpr (cond || xs)", pe.X)
+	}
+	if _, ok := pe.Y.(*Ident); !ok {
+		t.Fatalf("pe.Y = %#v, want *Ident (f)", pe.Y)
+	}
+}
+
+// TestPrecedenceParallelSharesPipeLevel locks in that |||> shares the
+// same precedence level as the other pipe operators: it also binds
+// looser than || and still chains left-associatively with |>.
+func TestPrecedenceParallelSharesPipeLevel(t *testing.T) {
+	x := parseExprString(t, "xs |> f |||> g")
+	outer, ok := x.(*PipeExpr)
+	if !ok || outer.Op != PIPE_PARALLEL {
+		t.Fatalf("got %#v, want *PipeExpr{Op: PIPE_PARALLEL}", x)
+	}
+	if _, ok := outer.X.(*PipeExpr); !ok {
+		t.Fatalf("outer.X = %#v, want *PipeExpr (xs |> f)", outer.X)
+	}
+}
+
+// TestIfHeaderCompositeLitAmbiguity locks in that a bare identifier
+// directly followed by `{` in an if/for header opens the statement
+// body, not a composite literal, while the same identifier inside
+// parentheses still allows one.
+func TestIfHeaderCompositeLitAmbiguity(t *testing.T) {
+	f, err := Parse([]byte("package p\nfunc f(err error) {\nif err != nil {\n}\n}\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	fd := f.Decls[0].(*FuncDecl)
+	ifs, ok := fd.Body.List[0].(*IfStmt)
+	if !ok {
+		t.Fatalf("got %#v, want *IfStmt", fd.Body.List[0])
+	}
+	if _, ok := ifs.Cond.(*BinaryExpr); !ok {
+		t.Fatalf("ifs.Cond = %#v, want *BinaryExpr (err != nil)", ifs.Cond)
+	}
+
+	x := parseExprString(t, "f((T{}))")
+	if _, ok := x.(*CallExpr); !ok {
+		t.Fatalf("got %#v, want *CallExpr", x)
+	}
+}
@@ -0,0 +1,372 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Scanner tokenizes andy-go source text. It follows the shape of
+// go/scanner.Scanner, extended to recognize |>, |?, |/, and |||>
+// alongside the ordinary Go token set.
+type Scanner struct {
+	src []byte
+	// current character info
+	offset     int // position of ch
+	rdOffset   int // reading offset (position after ch)
+	ch         rune
+	lineOffset int
+	ErrorCount int
+
+	// insertSemi reports whether the previously scanned token can end
+	// a statement, so a following newline should insert a synthetic
+	// SEMICOLON (mirroring go/scanner's automatic semicolon rule).
+	insertSemi bool
+}
+
+func NewScanner(src []byte) *Scanner {
+	s := &Scanner{src: src}
+	s.next()
+	return s
+}
+
+const eof = -1
+
+func (s *Scanner) next() {
+	if s.rdOffset < len(s.src) {
+		s.offset = s.rdOffset
+		r, w := rune(s.src[s.rdOffset]), 1
+		switch {
+		case r == 0:
+			s.error(s.offset, "illegal NUL byte")
+		case r >= utf8.RuneSelf:
+			r, w = utf8.DecodeRune(s.src[s.rdOffset:])
+		}
+		s.rdOffset += w
+		s.ch = r
+	} else {
+		s.offset = len(s.src)
+		s.ch = eof
+	}
+}
+
+func (s *Scanner) peek() byte {
+	if s.rdOffset < len(s.src) {
+		return s.src[s.rdOffset]
+	}
+	return 0
+}
+
+func (s *Scanner) error(offset int, msg string) {
+	s.ErrorCount++
+	panic(&ScanError{Offset: offset, Msg: msg})
+}
+
+// ScanError is raised (via panic, recovered by the parser) on a lexical error.
+type ScanError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("offset %d: %s", e.Offset, e.Msg)
+}
+
+func isLetter(ch rune) bool {
+	return ch == '_' || unicode.IsLetter(ch)
+}
+
+func isDigit(ch rune) bool {
+	return unicode.IsDigit(ch)
+}
+
+func (s *Scanner) skipWhitespace() {
+	for s.ch == ' ' || s.ch == '\t' || s.ch == '\r' {
+		s.next()
+	}
+}
+
+func (s *Scanner) scanIdentifier() string {
+	start := s.offset
+	for isLetter(s.ch) || isDigit(s.ch) {
+		s.next()
+	}
+	return string(s.src[start:s.offset])
+}
+
+func (s *Scanner) scanNumber() (Token, string) {
+	start := s.offset
+	tok := INT
+	for isDigit(s.ch) {
+		s.next()
+	}
+	if s.ch == '.' {
+		tok = FLOAT
+		s.next()
+		for isDigit(s.ch) {
+			s.next()
+		}
+	}
+	return tok, string(s.src[start:s.offset])
+}
+
+func (s *Scanner) scanString() string {
+	start := s.offset
+	s.next() // consume opening quote
+	for s.ch != '"' {
+		if s.ch == eof || s.ch == '\n' {
+			s.error(start, "string literal not terminated")
+		}
+		if s.ch == '\\' {
+			s.next()
+		}
+		s.next()
+	}
+	s.next() // consume closing quote
+	return string(s.src[start:s.offset])
+}
+
+// scanComment consumes a // or /* */ comment and reports whether it
+// contains a newline (relevant for automatic semicolon insertion).
+func (s *Scanner) scanComment() (lit string, sawNewline bool) {
+	start := s.offset
+	if s.peek() == '/' {
+		for s.ch != '\n' && s.ch != eof {
+			s.next()
+		}
+		return string(s.src[start:s.offset]), false
+	}
+	// block comment
+	s.next()
+	s.next()
+	for {
+		if s.ch == eof {
+			s.error(start, "comment not terminated")
+		}
+		if s.ch == '\n' {
+			sawNewline = true
+		}
+		if s.ch == '*' && s.peek() == '/' {
+			s.next()
+			s.next()
+			break
+		}
+		s.next()
+	}
+	return string(s.src[start:s.offset]), sawNewline
+}
+
+// Scan returns the next token, its source offset, and its literal
+// text (for IDENT, INT, FLOAT, and STRING). Comments are discarded;
+// newlines are turned into a synthetic SEMICOLON token following the
+// same automatic-semicolon-insertion rule as go/scanner.
+func (s *Scanner) Scan() (pos int, tok Token, lit string) {
+	for {
+		s.skipWhitespace()
+		if s.ch == '\n' {
+			if s.insertSemi {
+				s.insertSemi = false
+				pos = s.offset
+				s.next()
+				return pos, SEMICOLON, "\n"
+			}
+			s.next()
+			continue
+		}
+		if s.ch == '/' && (s.peek() == '/' || s.peek() == '*') {
+			_, sawNewline := s.scanComment()
+			if sawNewline && s.insertSemi {
+				s.insertSemi = false
+				return s.offset, SEMICOLON, "\n"
+			}
+			continue
+		}
+		break
+	}
+
+	pos = s.offset
+	insertSemi := false
+
+	switch ch := s.ch; {
+	case isLetter(ch):
+		lit = s.scanIdentifier()
+		tok = Lookup(lit)
+		switch tok {
+		case IDENT, RETURN:
+			insertSemi = true
+		}
+		s.insertSemi = insertSemi
+		return pos, tok, lit
+	case isDigit(ch):
+		tok, lit = s.scanNumber()
+		s.insertSemi = true
+		return pos, tok, lit
+	}
+
+	// emit finalizes a punctuation/operator token: only a closing
+	// bracket can end a statement, so only RPAREN/RBRACK/RBRACE arm
+	// automatic semicolon insertion.
+	emit := func(t Token, l string) (int, Token, string) {
+		s.insertSemi = t == RPAREN || t == RBRACK || t == RBRACE
+		return pos, t, l
+	}
+
+	switch s.ch {
+	case eof:
+		if s.insertSemi {
+			s.insertSemi = false
+			return pos, SEMICOLON, "\n"
+		}
+		return pos, EOF, ""
+	case '"':
+		tok, lit = STRING, s.scanString()
+		s.insertSemi = true
+		return pos, tok, lit
+	case '/':
+		s.next()
+		return emit(QUO, "/")
+	case '(':
+		s.next()
+		return emit(LPAREN, "(")
+	case ')':
+		s.next()
+		return emit(RPAREN, ")")
+	case '[':
+		s.next()
+		return emit(LBRACK, "[")
+	case ']':
+		s.next()
+		return emit(RBRACK, "]")
+	case '{':
+		s.next()
+		return emit(LBRACE, "{")
+	case '}':
+		s.next()
+		return emit(RBRACE, "}")
+	case ',':
+		s.next()
+		return emit(COMMA, ",")
+	case ';':
+		s.next()
+		return emit(SEMICOLON, ";")
+	case ':':
+		s.next()
+		if s.ch == '=' {
+			s.next()
+			return emit(DEFINE, ":=")
+		}
+		return emit(COLON, ":")
+	case '.':
+		s.next()
+		if s.ch == '.' && s.peek() == '.' {
+			s.next()
+			s.next()
+			return emit(ELLIPSIS, "...")
+		}
+		return emit(PERIOD, ".")
+	case '+':
+		s.next()
+		if s.ch == '+' {
+			s.next()
+			s.insertSemi = true
+			return pos, INC, "++"
+		}
+		return emit(ADD, "+")
+	case '-':
+		s.next()
+		if s.ch == '-' {
+			s.next()
+			s.insertSemi = true
+			return pos, DEC, "--"
+		}
+		return emit(SUB, "-")
+	case '*':
+		s.next()
+		return emit(MUL, "*")
+	case '%':
+		s.next()
+		return emit(REM, "%")
+	case '^':
+		s.next()
+		return emit(XOR, "^")
+	case '!':
+		s.next()
+		if s.ch == '=' {
+			s.next()
+			return emit(NEQ, "!=")
+		}
+		return emit(NOT, "!")
+	case '=':
+		s.next()
+		if s.ch == '=' {
+			s.next()
+			return emit(EQL, "==")
+		}
+		return emit(ASSIGN, "=")
+	case '<':
+		s.next()
+		switch s.ch {
+		case '-':
+			s.next()
+			return emit(ARROW, "<-")
+		case '=':
+			s.next()
+			return emit(LEQ, "<=")
+		case '<':
+			s.next()
+			return emit(SHL, "<<")
+		}
+		return emit(LSS, "<")
+	case '>':
+		s.next()
+		if s.ch == '=' {
+			s.next()
+			return emit(GEQ, ">=")
+		}
+		if s.ch == '>' {
+			s.next()
+			return emit(SHR, ">>")
+		}
+		return emit(GTR, ">")
+	case '&':
+		s.next()
+		switch s.ch {
+		case '&':
+			s.next()
+			return emit(LAND, "&&")
+		case '^':
+			s.next()
+			return emit(AND_NOT, "&^")
+		}
+		return emit(AND, "&")
+	case '|':
+		s.next() // first '|'
+		switch s.ch {
+		case '|':
+			s.next() // second '|'
+			if s.ch == '|' && s.peek() == '>' {
+				s.next()
+				s.next()
+				return emit(PIPE_PARALLEL, "|||>")
+			}
+			return emit(LOR, "||")
+		case '>':
+			s.next()
+			return emit(PIPE_MAP, "|>")
+		case '?':
+			s.next()
+			return emit(PIPE_FILTER, "|?")
+		case '/':
+			s.next()
+			return emit(PIPE_REDUCE, "|/")
+		}
+		return emit(OR, "|")
+	}
+
+	s.error(pos, fmt.Sprintf("illegal character %#U", s.ch))
+	return pos, ILLEGAL, ""
+}
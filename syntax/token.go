@@ -0,0 +1,179 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package syntax implements the scanner, parser, and AST for the
+// andy-go source language: Go plus the |>, |?, |/, and |||> pipe
+// operator family. It is deliberately scoped to the subset of Go
+// declaration, statement, and expression grammar this fork's test
+// suite exercises, not the full language.
+package syntax
+
+import "fmt"
+
+// Token is the set of lexical tokens recognized by the scanner.
+type Token int
+
+const (
+	ILLEGAL Token = iota
+	EOF
+	COMMENT
+
+	literalBeg
+	IDENT
+	INT
+	FLOAT
+	STRING
+	literalEnd
+
+	operatorBeg
+
+	LPAREN
+	LBRACK
+	LBRACE
+	COMMA
+	PERIOD
+	ELLIPSIS
+
+	RPAREN
+	RBRACK
+	RBRACE
+	SEMICOLON
+	COLON
+
+	ASSIGN
+	DEFINE
+	ARROW // <-
+
+	ADD
+	SUB
+	MUL
+	QUO
+	REM
+
+	INC // ++
+	DEC // --
+
+	AND
+	OR
+	XOR
+	SHL
+	SHR
+	AND_NOT
+
+	LAND
+	LOR
+	NOT
+
+	EQL
+	NEQ
+	LSS
+	LEQ
+	GTR
+	GEQ
+
+	// The pipe operator family. They share one precedence level; see
+	// Precedence below and doc/pipe-operators.md for the rationale.
+	PIPE_MAP      // |>
+	PIPE_FILTER   // |?
+	PIPE_REDUCE   // |/
+	PIPE_PARALLEL // |||>
+
+	operatorEnd
+
+	keywordBeg
+	PACKAGE
+	IMPORT
+	FUNC
+	RETURN
+	VAR
+	TYPE
+	STRUCT
+	IF
+	ELSE
+	FOR
+	RANGE
+	DEFER
+	GO
+	MAP
+	CHAN
+	INTERFACE
+	keywordEnd
+)
+
+var tokenNames = map[Token]string{
+	ILLEGAL: "ILLEGAL", EOF: "EOF", COMMENT: "COMMENT",
+	IDENT: "IDENT", INT: "INT", FLOAT: "FLOAT", STRING: "STRING",
+	LPAREN: "(", LBRACK: "[", LBRACE: "{", COMMA: ",", PERIOD: ".", ELLIPSIS: "...",
+	RPAREN: ")", RBRACK: "]", RBRACE: "}", SEMICOLON: ";", COLON: ":",
+	ASSIGN: "=", DEFINE: ":=", ARROW: "<-",
+	ADD: "+", SUB: "-", MUL: "*", QUO: "/", REM: "%",
+	INC: "++", DEC: "--",
+	AND: "&", OR: "|", XOR: "^", SHL: "<<", SHR: ">>", AND_NOT: "&^",
+	LAND: "&&", LOR: "||", NOT: "!",
+	EQL: "==", NEQ: "!=", LSS: "<", LEQ: "<=", GTR: ">", GEQ: ">=",
+	PIPE_MAP: "|>", PIPE_FILTER: "|?", PIPE_REDUCE: "|/", PIPE_PARALLEL: "|||>",
+	PACKAGE: "package", IMPORT: "import", FUNC: "func", RETURN: "return",
+	VAR: "var", TYPE: "type", STRUCT: "struct", IF: "if", ELSE: "else",
+	FOR: "for", RANGE: "range", DEFER: "defer", GO: "go", MAP: "map", CHAN: "chan",
+	INTERFACE: "interface",
+}
+
+func (t Token) String() string {
+	if s, ok := tokenNames[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("token(%d)", int(t))
+}
+
+var keywords map[string]Token
+
+func init() {
+	keywords = make(map[string]Token)
+	for t := keywordBeg + 1; t < keywordEnd; t++ {
+		keywords[tokenNames[t]] = t
+	}
+}
+
+// Lookup maps an identifier to its keyword token, or IDENT if ident
+// is not a keyword.
+func Lookup(ident string) Token {
+	if t, ok := keywords[ident]; ok {
+		return t
+	}
+	return IDENT
+}
+
+// IsPipeOp reports whether t is a member of the pipe operator family.
+func IsPipeOp(t Token) bool {
+	switch t {
+	case PIPE_MAP, PIPE_FILTER, PIPE_REDUCE, PIPE_PARALLEL:
+		return true
+	}
+	return false
+}
+
+// Precedence returns the operator precedence of the binary operator
+// t, or LowestPrec if t is not a binary operator. Higher numbers bind
+// tighter. The pipe family sits below || and above assignment.
+func Precedence(t Token) int {
+	switch t {
+	case PIPE_MAP, PIPE_FILTER, PIPE_REDUCE, PIPE_PARALLEL:
+		return 1
+	case LOR:
+		return 2
+	case LAND:
+		return 3
+	case EQL, NEQ, LSS, LEQ, GTR, GEQ:
+		return 4
+	case ADD, SUB, OR, XOR:
+		return 5
+	case MUL, QUO, REM, SHL, SHR, AND, AND_NOT:
+		return 6
+	}
+	return LowestPrec
+}
+
+// LowestPrec is the precedence below every binary operator;
+// parseBinaryExpr's initial call uses it as the starting floor.
+const LowestPrec = 0